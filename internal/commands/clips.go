@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// Clip is one file of a random-pick command folder.
+type Clip struct {
+	Filename  string // Path relative to static/chat
+	MediaType string // "audio" or "video"
+	Weight    int    // Relative chance of being picked (at least 1)
+}
+
+// clipPicker chooses clips for folder commands, avoiding the most recently played ones.
+type clipPicker struct {
+	intn func(int) int // Random source, replaced in tests
+
+	mu     sync.Mutex
+	recent map[string][]string // Command -> last played clip filenames, newest last
+}
+
+func newClipPicker() *clipPicker {
+	return &clipPicker{intn: rand.Intn, recent: make(map[string][]string)}
+}
+
+// Pick returns the clip to play for cmd. Single-file commands always return their own file.
+func (r *Registry) Pick(cmd MediaCommand) Clip {
+	if len(cmd.Clips) == 0 {
+		return Clip{Filename: cmd.Filename, MediaType: cmd.MediaType, Weight: 1}
+	}
+	return r.picker.pick(cmd)
+}
+
+// pick draws a weighted random clip outside the no-repeat window and records it.
+func (p *clipPicker) pick(cmd MediaCommand) Clip {
+	window := cmd.NoRepeat
+	if window <= 0 {
+		window = 1
+	}
+	if window > len(cmd.Clips)-1 {
+		window = len(cmd.Clips) - 1
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// The window is below the clip count, so at least one clip is always left
+	recent := p.recent[cmd.Name]
+	if len(recent) > window {
+		recent = recent[len(recent)-window:]
+	}
+	blocked := make(map[string]bool, len(recent))
+	for _, filename := range recent {
+		blocked[filename] = true
+	}
+
+	var candidates []Clip
+	total := 0
+	for _, clip := range cmd.Clips {
+		if blocked[clip.Filename] {
+			continue
+		}
+		if clip.Weight < 1 {
+			clip.Weight = 1
+		}
+		candidates = append(candidates, clip)
+		total += clip.Weight
+	}
+
+	chosen := candidates[len(candidates)-1]
+	n := p.intn(total)
+	for _, clip := range candidates {
+		if n < clip.Weight {
+			chosen = clip
+			break
+		}
+		n -= clip.Weight
+	}
+
+	if window > 0 {
+		p.recent[cmd.Name] = append(append([]string(nil), recent...), chosen.Filename)
+	}
+	return chosen
+}
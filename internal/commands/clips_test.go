@@ -0,0 +1,62 @@
+package commands
+
+import "testing"
+
+func TestPickNoRepeat(t *testing.T) {
+	r := NewRegistry(nil, nil)
+	cmd := MediaCommand{Name: "fart", Filename: "everyone/fart", NoRepeat: 2, Clips: []Clip{
+		{Filename: "everyone/fart/a.mp3", MediaType: "audio", Weight: 1},
+		{Filename: "everyone/fart/b.mp3", MediaType: "audio", Weight: 1},
+		{Filename: "everyone/fart/c.wav", MediaType: "audio", Weight: 1},
+	}}
+
+	// Always take the first candidate: the window forces a full rotation
+	r.picker.intn = func(int) int { return 0 }
+	var got []string
+	for i := 0; i < 6; i++ {
+		got = append(got, r.Pick(cmd).Filename)
+	}
+	want := []string{"a.mp3", "b.mp3", "c.wav", "a.mp3", "b.mp3", "c.wav"}
+	for i := range want {
+		if got[i] != "everyone/fart/"+want[i] {
+			t.Fatalf("Pick sequence = %v, want rotation %v", got, want)
+		}
+	}
+
+	// The window never blocks every clip
+	single := MediaCommand{Name: "solo", Clips: []Clip{{Filename: "everyone/solo/x.mp3", Weight: 1}}}
+	if r.Pick(single).Filename != "everyone/solo/x.mp3" || r.Pick(single).Filename != "everyone/solo/x.mp3" {
+		t.Error("A single clip must always be playable")
+	}
+
+	// Plain commands play their own file
+	if clip := r.Pick(MediaCommand{Filename: "everyone/hello.mp3", MediaType: "audio"}); clip.Filename != "everyone/hello.mp3" || clip.MediaType != "audio" {
+		t.Errorf("Unexpected clip %+v", clip)
+	}
+}
+
+func TestPickWeighted(t *testing.T) {
+	r := NewRegistry(nil, nil)
+	cmd := MediaCommand{Name: "fart", Clips: []Clip{
+		{Filename: "a.mp3", Weight: 1},
+		{Filename: "b.mp3", Weight: 3},
+	}}
+
+	var total int
+	r.picker.intn = func(n int) int {
+		total = n
+		return 2 // Falls inside b's share [1, 4)
+	}
+	if clip := r.Pick(cmd); clip.Filename != "b.mp3" || total != 4 {
+		t.Errorf("Expected b.mp3 out of total weight 4, got %s out of %d", clip.Filename, total)
+	}
+
+	// b is now blocked, so only a is left
+	r.picker.intn = func(n int) int {
+		total = n
+		return 0
+	}
+	if clip := r.Pick(cmd); clip.Filename != "a.mp3" || total != 1 {
+		t.Errorf("Expected a.mp3 after b, got %s out of %d", clip.Filename, total)
+	}
+}
@@ -0,0 +1,179 @@
+package commands
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"VLX_Robot/internal/config"
+)
+
+// pruneThreshold is the number of tracked users above which expired entries are dropped.
+const pruneThreshold = 10000
+
+// DefaultCommandCooldown applies when neither commands.command_cooldown nor twitch.chat.command_cooldown is set.
+const DefaultCommandCooldown = 15 * time.Second
+
+// Cooldown notice modes
+const (
+	NoticeReply   = "reply"
+	NoticeWhisper = "whisper"
+)
+
+// CooldownPolicy holds the cooldown durations. Zero disables a level.
+type CooldownPolicy struct {
+	Global  time.Duration // Between any two commands in a channel
+	Command time.Duration // Between two uses of the same command (default, commands may override it)
+	User    time.Duration // Between two uses of the same command by the same user
+	Bypass  bool          // Broadcaster and moderators ignore cooldowns
+	Notice  string        // How rejected users learn the remaining time: NoticeReply, NoticeWhisper or empty
+}
+
+// PolicyFromConfig builds the policy from the shared commands config.
+// legacyCommandCooldown is twitch.chat.command_cooldown, used when commands.command_cooldown is unset.
+func PolicyFromConfig(cfg config.CommandsConfig, legacyCommandCooldown int) CooldownPolicy {
+	policy := CooldownPolicy{
+		Global: time.Duration(cfg.GlobalCooldown) * time.Second,
+		User:   time.Duration(cfg.UserCooldown) * time.Second,
+		Bypass: cfg.ModBypass,
+		Notice: cfg.CooldownNotice,
+	}
+	switch {
+	case cfg.CommandCooldown > 0:
+		policy.Command = time.Duration(cfg.CommandCooldown) * time.Second
+	case legacyCommandCooldown > 0:
+		policy.Command = time.Duration(legacyCommandCooldown) * time.Second
+	default:
+		policy.Command = DefaultCommandCooldown
+	}
+	return policy
+}
+
+// Usage describes one command invocation.
+type Usage struct {
+	Scope      string        // Platform and channel, e.g. "twitch/streamer"; cooldowns never cross scopes
+	Command    string        // Command name
+	User       string        // Stable user ID or login
+	Privileged bool          // Broadcaster or moderator
+	Cooldown   time.Duration // Per-command override of the policy Command cooldown (0 = default)
+}
+
+// Verdict is the outcome of a cooldown check.
+type Verdict struct {
+	Allowed   bool
+	Remaining time.Duration // Wait time when not allowed
+	Notify    bool          // First rejection in this cooldown window: worth telling them
+}
+
+// Cooldowns tracks global, per-command and per-user cooldowns. It is safe for concurrent use.
+type Cooldowns struct {
+	policy CooldownPolicy
+	now    func() time.Time
+
+	mu          sync.Mutex
+	lastGlobal  map[string]time.Time // Scope -> last use
+	lastCommand map[string]time.Time // Scope/command -> last use
+	lastUser    map[string]time.Time // Scope/command/user -> last use
+	notified    map[string]time.Time // Scope/command/user -> end of the cooldown window already reported
+}
+
+// NewCooldowns creates an empty cooldown tracker.
+func NewCooldowns(policy CooldownPolicy) *Cooldowns {
+	return &Cooldowns{
+		policy:      policy,
+		now:         time.Now,
+		lastGlobal:  make(map[string]time.Time),
+		lastCommand: make(map[string]time.Time),
+		lastUser:    make(map[string]time.Time),
+		notified:    make(map[string]time.Time),
+	}
+}
+
+// Policy returns the configured policy.
+func (c *Cooldowns) Policy() CooldownPolicy {
+	return c.policy
+}
+
+// Check reports whether the usage may run and, if so, records it.
+func (c *Cooldowns) Check(u Usage) Verdict {
+	if u.Privileged && c.policy.Bypass {
+		return Verdict{Allowed: true}
+	}
+
+	commandCooldown := c.policy.Command
+	if u.Cooldown > 0 {
+		commandCooldown = u.Cooldown
+	}
+	commandKey := u.Scope + "/" + u.Command
+	userKey := commandKey + "/" + u.User
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	remaining := maxDuration(
+		remainingSince(c.lastGlobal[u.Scope], c.policy.Global, now),
+		remainingSince(c.lastCommand[commandKey], commandCooldown, now),
+		remainingSince(c.lastUser[userKey], c.policy.User, now),
+	)
+	if remaining > 0 {
+		notify := !now.Before(c.notified[userKey])
+		if notify {
+			c.notified[userKey] = now.Add(remaining)
+			if len(c.notified) > pruneThreshold {
+				c.pruneLocked(now)
+			}
+		}
+		return Verdict{Remaining: remaining, Notify: notify}
+	}
+
+	c.lastGlobal[u.Scope] = now
+	c.lastCommand[commandKey] = now
+	c.lastUser[userKey] = now
+	delete(c.notified, userKey)
+	if len(c.lastUser) > pruneThreshold {
+		c.pruneLocked(now)
+	}
+	return Verdict{Allowed: true}
+}
+
+// pruneLocked drops per-user entries whose cooldown or reported window has expired. c.mu must be held.
+func (c *Cooldowns) pruneLocked(now time.Time) {
+	for key, last := range c.lastUser {
+		if now.Sub(last) >= c.policy.User {
+			delete(c.lastUser, key)
+		}
+	}
+	for key, until := range c.notified {
+		if !now.Before(until) {
+			delete(c.notified, key)
+		}
+	}
+}
+
+// NoticeText is the reply sent to a user who hit a cooldown.
+func NoticeText(user, command string, remaining time.Duration) string {
+	seconds := int((remaining + time.Second - 1) / time.Second)
+	return fmt.Sprintf("@%s !%s is on cooldown, try again in %ds.", user, command, seconds)
+}
+
+// remainingSince returns how much of cooldown is left since last (0 if expired or never used).
+func remainingSince(last time.Time, cooldown time.Duration, now time.Time) time.Duration {
+	if last.IsZero() || cooldown <= 0 {
+		return 0
+	}
+	if left := last.Add(cooldown).Sub(now); left > 0 {
+		return left
+	}
+	return 0
+}
+
+func maxDuration(durations ...time.Duration) time.Duration {
+	var max time.Duration
+	for _, d := range durations {
+		if d > max {
+			max = d
+		}
+	}
+	return max
+}
@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"VLX_Robot/internal/config"
+)
+
+func TestCooldownLevels(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cd := NewCooldowns(CooldownPolicy{Global: 2 * time.Second, Command: 10 * time.Second, User: 30 * time.Second})
+	cd.now = func() time.Time { return now }
+
+	use := func(command, user string) Verdict {
+		return cd.Check(Usage{Scope: "twitch/streamer", Command: command, User: user})
+	}
+
+	if !use("hello", "alice").Allowed {
+		t.Fatal("First use must be allowed")
+	}
+
+	// Global cooldown blocks any command right after
+	if v := use("bye", "bob"); v.Allowed || v.Remaining != 2*time.Second {
+		t.Errorf("Expected global cooldown, got %+v", v)
+	}
+
+	now = now.Add(3 * time.Second)
+	if !use("bye", "bob").Allowed {
+		t.Error("Other command must be allowed after the global cooldown")
+	}
+
+	// Per-command cooldown blocks other users
+	now = now.Add(3 * time.Second)
+	if v := use("hello", "bob"); v.Allowed || v.Remaining != 4*time.Second {
+		t.Errorf("Expected command cooldown, got %+v", v)
+	}
+
+	// Per-user cooldown outlasts the command cooldown
+	now = now.Add(10 * time.Second)
+	if !use("hello", "bob").Allowed {
+		t.Error("Other user must be allowed after the command cooldown")
+	}
+	now = now.Add(11 * time.Second)
+	if v := use("hello", "alice"); v.Allowed || v.Remaining != 3*time.Second {
+		t.Errorf("Expected user cooldown, got %+v", v)
+	}
+
+	// Scopes are independent
+	if !cd.Check(Usage{Scope: "youtube/UC1", Command: "hello", User: "alice"}).Allowed {
+		t.Error("Cooldowns must not cross scopes")
+	}
+}
+
+func TestCooldownOverrideAndBypass(t *testing.T) {
+	now := time.Now()
+	cd := NewCooldowns(CooldownPolicy{Command: 10 * time.Second, Bypass: true})
+	cd.now = func() time.Time { return now }
+
+	cd.Check(Usage{Scope: "s", Command: "discord", User: "a", Cooldown: time.Minute})
+	now = now.Add(30 * time.Second)
+	if v := cd.Check(Usage{Scope: "s", Command: "discord", User: "b", Cooldown: time.Minute}); v.Allowed {
+		t.Error("Per-command override must replace the default cooldown")
+	}
+	if !cd.Check(Usage{Scope: "s", Command: "discord", User: "mod", Privileged: true}).Allowed {
+		t.Error("Moderators must bypass cooldowns when enabled")
+	}
+
+	strict := NewCooldowns(CooldownPolicy{Command: 10 * time.Second})
+	strict.Check(Usage{Scope: "s", Command: "x", User: "a"})
+	if strict.Check(Usage{Scope: "s", Command: "x", User: "mod", Privileged: true}).Allowed {
+		t.Error("Moderators must respect cooldowns when bypass is disabled")
+	}
+}
+
+func TestCooldownNotifyOnce(t *testing.T) {
+	cd := NewCooldowns(CooldownPolicy{Command: time.Minute})
+	u := Usage{Scope: "s", Command: "x", User: "a"}
+	cd.Check(u)
+
+	if v := cd.Check(u); !v.Notify {
+		t.Error("First rejection must notify")
+	}
+	if v := cd.Check(u); v.Notify {
+		t.Error("Repeated rejections must not notify again")
+	}
+	if got := NoticeText("Alice", "x", 1500*time.Millisecond); got != "@Alice !x is on cooldown, try again in 2s." {
+		t.Errorf("Unexpected notice %q", got)
+	}
+}
+
+func TestCooldownNotifyPerWindow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cd := NewCooldowns(CooldownPolicy{Command: time.Minute})
+	cd.now = func() time.Time { return now }
+	alice := Usage{Scope: "s", Command: "x", User: "alice"}
+	bob := Usage{Scope: "s", Command: "x", User: "bob"}
+
+	// Bob is only ever rejected: he is told once per cooldown window
+	cd.Check(alice)
+	now = now.Add(10 * time.Second)
+	if v := cd.Check(bob); !v.Notify {
+		t.Error("First rejection must notify")
+	}
+	now = now.Add(10 * time.Second)
+	if v := cd.Check(bob); v.Notify {
+		t.Error("Rejections in the same window must not notify again")
+	}
+	now = now.Add(41 * time.Second)
+	cd.Check(alice)
+	now = now.Add(10 * time.Second)
+	if v := cd.Check(bob); v.Allowed || !v.Notify {
+		t.Errorf("Expected a new notice in the next window, got %+v", v)
+	}
+
+	// Reported windows expire with the cooldown
+	now = now.Add(time.Minute)
+	cd.pruneLocked(now)
+	if len(cd.notified) != 0 {
+		t.Errorf("Expected expired notices to be pruned, got %v", cd.notified)
+	}
+}
+
+func TestPolicyFromConfig(t *testing.T) {
+	if p := PolicyFromConfig(config.CommandsConfig{}, 0); p.Command != DefaultCommandCooldown {
+		t.Errorf("Expected default command cooldown, got %v", p.Command)
+	}
+	if p := PolicyFromConfig(config.CommandsConfig{}, 20); p.Command != 20*time.Second {
+		t.Errorf("Expected legacy command cooldown, got %v", p.Command)
+	}
+	p := PolicyFromConfig(config.CommandsConfig{CommandCooldown: 5, UserCooldown: 60, ModBypass: true, CooldownNotice: NoticeReply}, 20)
+	if p.Command != 5*time.Second || p.User != time.Minute || !p.Bypass || p.Notice != NoticeReply {
+		t.Errorf("Unexpected policy %+v", p)
+	}
+}
@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"sync"
+	"time"
+)
+
+// MediaCommand is a chat command that plays a file from static/chat.
+// Everything after MediaType comes from the optional metadata sidecar or folder manifest.
+type MediaCommand struct {
+	Name        string // Canonical name; alias entries share it (defaults to the map key)
+	Filename    string // File, or folder of a random-pick command
+	Permission  string
+	MediaType   string // "audio" or "video" (empty for random-pick folders)
+	Clips       []Clip // Files of a random-pick folder; one is chosen per use (see Pick)
+	NoRepeat    int    // Clips that must play before one repeats (default 1)
+	Aliases     []string
+	Volume      int           // 1-100, relative to the overlay volume (0 = full)
+	Description string        // Shown by !commands <name>
+	Cooldown    time.Duration // Overrides the default per-command cooldown (0 = default)
+	Cost        int           // Channel Points price, shown in !commands
+	Hidden      bool          // Left out of the !commands listing
+}
+
+// Stats are the usage counters of one command since startup.
+type Stats struct {
+	Uses     int64
+	Rejected int64 // Blocked by a cooldown
+	LastUsed time.Time
+}
+
+// Registry is the command state shared by the Twitch and YouTube bots:
+// media command definitions, cooldowns, clip history and usage stats. It is safe for concurrent use.
+type Registry struct {
+	cooldowns *Cooldowns
+	picker    *clipPicker
+
+	mu    sync.RWMutex
+	media map[string]MediaCommand
+	stats map[string]Stats
+}
+
+// NewRegistry creates a registry with the given media commands. A nil cooldowns tracker disables cooldowns.
+func NewRegistry(media map[string]MediaCommand, cooldowns *Cooldowns) *Registry {
+	if cooldowns == nil {
+		cooldowns = NewCooldowns(CooldownPolicy{})
+	}
+	r := &Registry{
+		cooldowns: cooldowns,
+		picker:    newClipPicker(),
+		stats:     make(map[string]Stats),
+	}
+	r.SetMedia(media)
+	return r
+}
+
+// Media returns a media command by name.
+func (r *Registry) Media(name string) (MediaCommand, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cmd, ok := r.media[name]
+	return cmd, ok
+}
+
+// MediaCommands returns a snapshot of every media command.
+func (r *Registry) MediaCommands() map[string]MediaCommand {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	snapshot := make(map[string]MediaCommand, len(r.media))
+	for name, cmd := range r.media {
+		snapshot[name] = cmd
+	}
+	return snapshot
+}
+
+// SetMedia replaces the media commands in one step. Aliases are separate entries pointing to their command.
+func (r *Registry) SetMedia(media map[string]MediaCommand) {
+	copied := make(map[string]MediaCommand, len(media))
+	for name, cmd := range media {
+		if cmd.Name == "" {
+			cmd.Name = name
+		}
+		copied[name] = cmd
+	}
+	r.mu.Lock()
+	r.media = copied
+	r.mu.Unlock()
+}
+
+// Use checks the cooldowns for an invocation and records it in the usage stats.
+func (r *Registry) Use(u Usage) Verdict {
+	verdict := r.cooldowns.Check(u)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := r.stats[u.Command]
+	if verdict.Allowed {
+		stats.Uses++
+		stats.LastUsed = time.Now()
+	} else {
+		stats.Rejected++
+	}
+	r.stats[u.Command] = stats
+	return verdict
+}
+
+// Stats returns the usage stats of a command.
+func (r *Registry) Stats(name string) Stats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.stats[name]
+}
+
+// Policy returns the cooldown policy.
+func (r *Registry) Policy() CooldownPolicy {
+	return r.cooldowns.Policy()
+}
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRegistryConcurrentUse(t *testing.T) {
+	r := NewRegistry(map[string]MediaCommand{"hello": {Filename: "everyone/hello.mp3"}}, NewCooldowns(CooldownPolicy{Command: time.Hour}))
+
+	// Both platforms hammer the same command while the media set is being replaced
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			scope := "twitch/streamer"
+			if i%2 == 1 {
+				scope = "youtube/UC1"
+			}
+			for j := 0; j < 20; j++ {
+				if _, ok := r.Media("hello"); ok {
+					r.Use(Usage{Scope: scope, Command: "hello", User: fmt.Sprintf("user-%d", i)})
+				}
+				_ = r.MediaCommands()
+			}
+		}(i)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 20; j++ {
+			r.SetMedia(map[string]MediaCommand{"hello": {Filename: "everyone/hello.mp3"}})
+		}
+	}()
+	wg.Wait()
+
+	// One use per scope passes the hour-long cooldown, every other attempt is rejected
+	stats := r.Stats("hello")
+	if stats.Uses != 2 || stats.Rejected != 50*20-2 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestRegistrySetMediaCopies(t *testing.T) {
+	media := map[string]MediaCommand{"a": {Filename: "a.mp3"}}
+	r := NewRegistry(media, nil)
+	media["b"] = MediaCommand{Filename: "b.mp3"}
+
+	if _, ok := r.Media("b"); ok {
+		t.Error("Registry must not share the caller's map")
+	}
+	snapshot := r.MediaCommands()
+	delete(snapshot, "a")
+	if _, ok := r.Media("a"); !ok {
+		t.Error("Snapshots must not alias the registry")
+	}
+}
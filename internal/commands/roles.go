@@ -0,0 +1,233 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"VLX_Robot/internal/config"
+)
+
+// Built-in roles, one per default static/chat folder
+const (
+	RoleEveryone   = "everyone"   // Public/Followers
+	RoleSubscriber = "subscriber" // Paid Subscribers / YouTube members
+	RoleVIP        = "vip"        // VIP/Mods
+)
+
+// Viewer holds the platform attributes of a chat user that roles are matched against.
+type Viewer struct {
+	Privileged bool           // Broadcaster, moderator or YouTube owner: passes every role
+	Badges     map[string]int // Twitch badges and their versions
+	SubMonths  int            // Twitch subscription months from badge-info
+	// FollowedAt looks up when the Twitch user followed the channel; false when not following or unknown.
+	// It is only called for roles with a follow age.
+	FollowedAt func() (time.Time, bool)
+
+	Sponsor   bool // YouTube member
+	Moderator bool // YouTube moderator
+	Owner     bool // YouTube channel owner
+	YouTube   bool // The attributes come from YouTube
+}
+
+// Role is a named set of conditions.
+type Role struct {
+	Name    string
+	Folder  string // static/chat subfolder holding the role's media commands
+	Twitch  config.RoleTwitchConfig
+	YouTube config.RoleYouTubeConfig
+}
+
+// Roles resolves role names for static/chat folders and permission checks. It is read-only once built;
+// a nil *Roles holds the built-in roles.
+type Roles struct {
+	roles map[string]Role
+	now   func() time.Time
+}
+
+// defaultRoles reproduces the original everyone/subscribers/vips folders.
+var defaultRoles = []Role{
+	{Name: RoleEveryone, Folder: "everyone"},
+	{Name: RoleSubscriber, Folder: "subscribers",
+		Twitch:  config.RoleTwitchConfig{Badges: []string{"subscriber", "founder"}},
+		YouTube: config.RoleYouTubeConfig{Sponsor: true}},
+	{Name: RoleVIP, Folder: "vips",
+		Twitch:  config.RoleTwitchConfig{Badges: []string{"vip"}},
+		YouTube: config.RoleYouTubeConfig{Moderator: true}},
+}
+
+// builtinRoles backs a nil *Roles.
+var builtinRoles = DefaultRoles()
+
+// DefaultRoles returns the built-in everyone, subscriber and vip roles.
+func DefaultRoles() *Roles {
+	roles, _ := RolesFromConfig(nil)
+	return roles
+}
+
+// orDefault lets a nil *Roles behave like the built-in roles.
+func (r *Roles) orDefault() *Roles {
+	if r == nil {
+		return builtinRoles
+	}
+	return r
+}
+
+// RolesFromConfig builds the built-in roles plus the configured ones. A configured role replaces a built-in of the same name.
+func RolesFromConfig(cfg []config.RoleConfig) (*Roles, error) {
+	r := &Roles{roles: make(map[string]Role), now: time.Now}
+	for _, role := range defaultRoles {
+		r.roles[role.Name] = role
+	}
+
+	for _, rc := range cfg {
+		name := strings.ToLower(strings.TrimSpace(rc.Name))
+		if name == "" {
+			return nil, fmt.Errorf("role without a name")
+		}
+		folder := rc.Folder
+		if builtin, ok := r.roles[name]; ok && folder == "" {
+			folder = builtin.Folder // Keep subscribers/ and vips/ when only the conditions change
+		}
+		if folder == "" {
+			folder = name
+		}
+		if rc.Twitch.MinTier < 0 || rc.Twitch.MinTier > 3 {
+			return nil, fmt.Errorf("role %s: min_tier must be between 1 and 3", name)
+		}
+		r.roles[name] = Role{Name: name, Folder: folder, Twitch: rc.Twitch, YouTube: rc.YouTube}
+	}
+
+	folders := make(map[string]string, len(r.roles))
+	for _, role := range r.roles {
+		if other, taken := folders[role.Folder]; taken {
+			return nil, fmt.Errorf("roles %s and %s share the folder %q", other, role.Name, role.Folder)
+		}
+		folders[role.Folder] = role.Name
+	}
+	return r, nil
+}
+
+// Has reports whether a role exists.
+func (r *Roles) Has(name string) bool {
+	r = r.orDefault()
+	_, ok := r.roles[name]
+	return ok
+}
+
+// Names returns the role names, sorted.
+func (r *Roles) Names() []string {
+	r = r.orDefault()
+	names := make([]string, 0, len(r.roles))
+	for name := range r.roles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Folder returns the static/chat subfolder of a role (the name itself for unknown roles).
+func (r *Roles) Folder(name string) string {
+	r = r.orDefault()
+	if role, ok := r.roles[name]; ok {
+		return role.Folder
+	}
+	return name
+}
+
+// Folders maps each static/chat subfolder to its role.
+func (r *Roles) Folders() map[string]string {
+	r = r.orDefault()
+	folders := make(map[string]string, len(r.roles))
+	for _, role := range r.roles {
+		folders[role.Folder] = role.Name
+	}
+	return folders
+}
+
+// Allows reports whether the viewer holds the role. Unknown roles allow nobody but privileged users.
+// On a platform where the role has no condition, only privileged users hold it (unless the role has none at all).
+func (r *Roles) Allows(name string, v Viewer) bool {
+	if v.Privileged {
+		return true
+	}
+	r = r.orDefault()
+	role, ok := r.roles[name]
+	if !ok {
+		return false
+	}
+	if !role.hasTwitchConditions() && !role.hasYouTubeConditions() {
+		return true
+	}
+	if v.YouTube {
+		return role.allowsYouTube(v)
+	}
+	return role.hasTwitchConditions() && r.allowsTwitch(role.Twitch, v)
+}
+
+func (role Role) hasTwitchConditions() bool {
+	t := role.Twitch
+	return len(t.Badges) > 0 || t.MinMonths > 0 || t.MinTier > 0 || t.FollowDays > 0
+}
+
+func (role Role) hasYouTubeConditions() bool {
+	y := role.YouTube
+	return y.Sponsor || y.Moderator || y.Owner
+}
+
+func (role Role) allowsYouTube(v Viewer) bool {
+	y := role.YouTube
+	return (y.Sponsor && v.Sponsor) || (y.Moderator && v.Moderator) || (y.Owner && v.Owner)
+}
+
+// allowsTwitch checks the cheap badge conditions first so the follow lookup only runs when needed.
+func (r *Roles) allowsTwitch(t config.RoleTwitchConfig, v Viewer) bool {
+	if len(t.Badges) > 0 {
+		found := false
+		for _, badge := range t.Badges {
+			if _, ok := v.Badges[badge]; ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if t.MinMonths > 0 && v.SubMonths < t.MinMonths {
+		return false
+	}
+	if t.MinTier > 0 && subTier(v.Badges) < t.MinTier {
+		return false
+	}
+	if t.FollowDays > 0 {
+		if v.FollowedAt == nil {
+			return false
+		}
+		since, ok := v.FollowedAt()
+		if !ok || r.now().Sub(since) < time.Duration(t.FollowDays)*24*time.Hour {
+			return false
+		}
+	}
+	return true
+}
+
+// subTier derives the subscription tier from the badge version (tier 2 and 3 badges start at 2000 and 3000).
+// Founders count as tier 1, their badge does not carry the tier. Returns 0 for non-subscribers.
+func subTier(badges map[string]int) int {
+	if version, ok := badges["subscriber"]; ok {
+		switch {
+		case version >= 3000:
+			return 3
+		case version >= 2000:
+			return 2
+		default:
+			return 1
+		}
+	}
+	if _, ok := badges["founder"]; ok {
+		return 1
+	}
+	return 0
+}
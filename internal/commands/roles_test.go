@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"VLX_Robot/internal/config"
+)
+
+func TestDefaultRoles(t *testing.T) {
+	roles := DefaultRoles()
+	if folders := roles.Folders(); folders["everyone"] != RoleEveryone || folders["subscribers"] != RoleSubscriber || folders["vips"] != RoleVIP {
+		t.Errorf("Unexpected default folders %v", folders)
+	}
+
+	tests := []struct {
+		name   string
+		role   string
+		viewer Viewer
+		want   bool
+	}{
+		{"Everyone", RoleEveryone, Viewer{}, true},
+		{"Twitch_Sub", RoleSubscriber, Viewer{Badges: map[string]int{"subscriber": 12}}, true},
+		{"Twitch_NoSub", RoleSubscriber, Viewer{Badges: map[string]int{"vip": 1}}, false},
+		{"Twitch_Mod", RoleVIP, Viewer{Privileged: true}, true},
+		{"YouTube_Member", RoleSubscriber, Viewer{YouTube: true, Sponsor: true}, true},
+		{"YouTube_MemberNotVIP", RoleVIP, Viewer{YouTube: true, Sponsor: true}, false},
+		{"YouTube_Viewer", RoleEveryone, Viewer{YouTube: true}, true},
+		{"Unknown", "admins", Viewer{Badges: map[string]int{"subscriber": 1}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := roles.Allows(tt.role, tt.viewer); got != tt.want {
+				t.Errorf("Allows(%s) = %v, want %v", tt.role, got, tt.want)
+			}
+		})
+	}
+
+	var nilRoles *Roles
+	if !nilRoles.Allows(RoleSubscriber, Viewer{Badges: map[string]int{"founder": 0}}) {
+		t.Error("A nil Roles must behave like the built-in roles")
+	}
+}
+
+func TestConfiguredRoles(t *testing.T) {
+	roles, err := RolesFromConfig([]config.RoleConfig{
+		{Name: "Founders", Twitch: config.RoleTwitchConfig{Badges: []string{"founder"}}},
+		{Name: "tier3", Twitch: config.RoleTwitchConfig{MinTier: 3}, YouTube: config.RoleYouTubeConfig{Sponsor: true}},
+		{Name: "veterans", Folder: "loyal", Twitch: config.RoleTwitchConfig{MinMonths: 12}},
+		{Name: "followers", Twitch: config.RoleTwitchConfig{FollowDays: 30}},
+	})
+	if err != nil {
+		t.Fatalf("RolesFromConfig failed: %v", err)
+	}
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	roles.now = func() time.Time { return now }
+
+	if roles.Folders()["loyal"] != "veterans" || roles.Folder("founders") != "founders" {
+		t.Errorf("Unexpected folders %v", roles.Folders())
+	}
+
+	followed := func(days int) func() (time.Time, bool) {
+		return func() (time.Time, bool) { return now.Add(-time.Duration(days) * 24 * time.Hour), true }
+	}
+	tests := []struct {
+		name   string
+		role   string
+		viewer Viewer
+		want   bool
+	}{
+		{"Founder", "founders", Viewer{Badges: map[string]int{"founder": 0}}, true},
+		{"Founder_Sub", "founders", Viewer{Badges: map[string]int{"subscriber": 24}}, false},
+		{"Tier3", "tier3", Viewer{Badges: map[string]int{"subscriber": 3012}}, true},
+		{"Tier2", "tier3", Viewer{Badges: map[string]int{"subscriber": 2012}}, false},
+		{"Tier3_YouTubeMember", "tier3", Viewer{YouTube: true, Sponsor: true}, true},
+		{"Months", "veterans", Viewer{SubMonths: 14}, true},
+		{"Months_Short", "veterans", Viewer{SubMonths: 3}, false},
+		{"Veterans_YouTube", "veterans", Viewer{YouTube: true, Sponsor: true}, false},
+		{"Veterans_YouTubeMod", "veterans", Viewer{YouTube: true, Privileged: true}, true},
+		{"Follow_Old", "followers", Viewer{FollowedAt: followed(45)}, true},
+		{"Follow_New", "followers", Viewer{FollowedAt: followed(3)}, false},
+		{"Follow_Unknown", "followers", Viewer{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := roles.Allows(tt.role, tt.viewer); got != tt.want {
+				t.Errorf("Allows(%s) = %v, want %v", tt.role, got, tt.want)
+			}
+		})
+	}
+
+	// The follow lookup is skipped when a cheaper condition already fails
+	strict, _ := RolesFromConfig([]config.RoleConfig{{Name: "subfollowers", Twitch: config.RoleTwitchConfig{Badges: []string{"subscriber"}, FollowDays: 1}}})
+	lookups := 0
+	strict.Allows("subfollowers", Viewer{FollowedAt: func() (time.Time, bool) { lookups++; return time.Time{}, false }})
+	if lookups != 0 {
+		t.Errorf("Expected no follow lookup, got %d", lookups)
+	}
+}
+
+func TestRolesFromConfigErrors(t *testing.T) {
+	if _, err := RolesFromConfig([]config.RoleConfig{{Name: "mods", Folder: "vips"}}); err == nil {
+		t.Error("Expected error for a folder shared with a built-in role")
+	}
+	if roles, err := RolesFromConfig([]config.RoleConfig{{Name: "subscriber", Twitch: config.RoleTwitchConfig{MinTier: 2}}}); err != nil || roles.Folder(RoleSubscriber) != "subscribers" {
+		t.Errorf("Overriding a built-in must keep its folder, got %v", err)
+	}
+	if _, err := RolesFromConfig([]config.RoleConfig{{Folder: "x"}}); err == nil {
+		t.Error("Expected error for a role without a name")
+	}
+	if _, err := RolesFromConfig([]config.RoleConfig{{Name: "x", Twitch: config.RoleTwitchConfig{MinTier: 4}}}); err == nil {
+		t.Error("Expected error for an invalid tier")
+	}
+}
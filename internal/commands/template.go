@@ -0,0 +1,165 @@
+// Package commands holds the chat command logic shared by the Twitch and YouTube bots.
+package commands
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// variablePattern matches ${name} and ${name arguments}.
+var variablePattern = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// Context carries the values of one command invocation.
+type Context struct {
+	Command string   // Command name, keys the ${count} counter
+	User    string   // Display name of the caller
+	Args    []string // Words after the command
+	Channel string   // Channel the command was used in, for ${uptime} and ${game}
+}
+
+// StreamStatus is the live data behind ${uptime} and ${game}.
+type StreamStatus struct {
+	Live      bool
+	StartedAt time.Time
+	Game      string
+}
+
+// StreamInfo supplies stream data for a channel (Helix on Twitch).
+type StreamInfo interface {
+	StreamStatus(channel string) (StreamStatus, error)
+}
+
+// Counter persists per-command usage counters for ${count}.
+type Counter interface {
+	IncrementCommandCounter(name string) (int64, error)
+}
+
+// Engine expands variables in command responses.
+type Engine struct {
+	counter Counter
+	stream  StreamInfo    // Optional: ${uptime} and ${game} report unavailable without it
+	pick    func(int) int // Random index source for ${random.pick}
+	now     func() time.Time
+	logger  *zap.Logger
+}
+
+// NewEngine creates a template engine. stream may be nil.
+func NewEngine(counter Counter, stream StreamInfo, logger *zap.Logger) *Engine {
+	return &Engine{
+		counter: counter,
+		stream:  stream,
+		pick:    rand.Intn,
+		now:     time.Now,
+		logger:  logger,
+	}
+}
+
+// Render expands every variable of tmpl. Unknown variables are left untouched.
+// Supported: ${user}, ${touser}, ${args}, ${count}, ${uptime}, ${game}, ${random.pick a,b,c}.
+func (e *Engine) Render(tmpl string, ctx Context) string {
+	if !strings.Contains(tmpl, "${") {
+		return tmpl
+	}
+
+	// Counter and stream data are fetched at most once per render
+	var count string
+	var status *StreamStatus
+
+	return variablePattern.ReplaceAllStringFunc(tmpl, func(match string) string {
+		name, arg, _ := strings.Cut(strings.TrimSpace(match[2:len(match)-1]), " ")
+
+		switch name {
+		case "user":
+			return ctx.User
+		case "touser":
+			if len(ctx.Args) > 0 {
+				return strings.TrimPrefix(ctx.Args[0], "@")
+			}
+			return ctx.User
+		case "args":
+			return strings.Join(ctx.Args, " ")
+		case "count":
+			if count == "" {
+				count = e.count(ctx.Command)
+			}
+			return count
+		case "uptime":
+			if status == nil {
+				status = e.streamStatus(ctx.Channel)
+			}
+			if !status.Live {
+				return "offline"
+			}
+			return formatUptime(e.now().Sub(status.StartedAt))
+		case "game":
+			if status == nil {
+				status = e.streamStatus(ctx.Channel)
+			}
+			if status.Game == "" {
+				return "unknown"
+			}
+			return status.Game
+		case "random.pick":
+			var options []string
+			for _, option := range strings.Split(arg, ",") {
+				if option = strings.TrimSpace(option); option != "" {
+					options = append(options, option)
+				}
+			}
+			if len(options) == 0 {
+				return ""
+			}
+			return options[e.pick(len(options))]
+		default:
+			return match
+		}
+	})
+}
+
+// count increments and returns the persistent counter of a command.
+func (e *Engine) count(command string) string {
+	if e.counter == nil {
+		return "0"
+	}
+	n, err := e.counter.IncrementCommandCounter(command)
+	if err != nil {
+		e.logger.Warn("Failed to increment command counter", zap.String("command", command), zap.Error(err))
+		return "?"
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// streamStatus loads stream data, treating errors as an offline stream.
+func (e *Engine) streamStatus(channel string) *StreamStatus {
+	if e.stream == nil {
+		return &StreamStatus{}
+	}
+	status, err := e.stream.StreamStatus(channel)
+	if err != nil {
+		e.logger.Warn("Failed to load stream status", zap.String("channel", channel), zap.Error(err))
+		return &StreamStatus{}
+	}
+	return &status
+}
+
+// formatUptime renders a duration as "2h 5m", "5m 3s" or "42s".
+func formatUptime(d time.Duration) string {
+	d = d.Truncate(time.Second)
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	s := int(d.Seconds()) % 60
+	switch {
+	case h > 0:
+		return fmt.Sprintf("%dh %dm", h, m)
+	case m > 0:
+		return fmt.Sprintf("%dm %ds", m, s)
+	default:
+		return fmt.Sprintf("%ds", s)
+	}
+}
@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"VLX_Robot/internal/database"
+
+	"go.uber.org/zap"
+)
+
+type fakeStreamInfo struct {
+	status StreamStatus
+	err    error
+	calls  int
+}
+
+func (f *fakeStreamInfo) StreamStatus(channel string) (StreamStatus, error) {
+	f.calls++
+	return f.status, f.err
+}
+
+func newTestEngine(stream StreamInfo) *Engine {
+	e := NewEngine(database.NewMemoryStore(), stream, zap.NewNop())
+	e.now = func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) }
+	return e
+}
+
+func TestRenderUserVariables(t *testing.T) {
+	e := newTestEngine(nil)
+
+	tests := []struct {
+		name string
+		tmpl string
+		args []string
+		want string
+	}{
+		{"User", "Hi ${user}!", nil, "Hi Caller!"},
+		{"ToUser_Arg", "Hug for ${touser}", []string{"@Friend", "extra"}, "Hug for Friend"},
+		{"ToUser_Fallback", "Hug for ${touser}", nil, "Hug for Caller"},
+		{"Args", "You said: ${args}", []string{"hello", "world"}, "You said: hello world"},
+		{"Unknown", "Keep ${nope} as is", nil, "Keep ${nope} as is"},
+		{"NoVariables", "Plain text", nil, "Plain text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := e.Render(tt.tmpl, Context{Command: "test", User: "Caller", Args: tt.args})
+			if got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderCount(t *testing.T) {
+	e := newTestEngine(nil)
+	ctx := Context{Command: "deaths"}
+
+	if got := e.Render("Deaths: ${count}", ctx); got != "Deaths: 1" {
+		t.Errorf("First render = %q", got)
+	}
+	// Several ${count} in one response share a single increment
+	if got := e.Render("${count} / ${count}", ctx); got != "2 / 2" {
+		t.Errorf("Second render = %q", got)
+	}
+	if got := e.Render("${count}", Context{Command: "other"}); got != "1" {
+		t.Errorf("Counters must be per command, got %q", got)
+	}
+}
+
+func TestRenderUptime(t *testing.T) {
+	live := &fakeStreamInfo{status: StreamStatus{Live: true, StartedAt: time.Date(2024, 1, 1, 9, 55, 30, 0, time.UTC)}}
+	if got := newTestEngine(live).Render("Live for ${uptime}", Context{Channel: "streamer"}); got != "Live for 2h 4m" {
+		t.Errorf("Render() = %q", got)
+	}
+
+	offline := &fakeStreamInfo{}
+	if got := newTestEngine(offline).Render("${uptime}", Context{}); got != "offline" {
+		t.Errorf("Render() = %q, want offline", got)
+	}
+
+	failing := &fakeStreamInfo{err: errors.New("helix down")}
+	if got := newTestEngine(failing).Render("${uptime}", Context{}); got != "offline" {
+		t.Errorf("Render() = %q, want offline on error", got)
+	}
+
+	if got := formatUptime(3*time.Minute + 7*time.Second); got != "3m 7s" {
+		t.Errorf("formatUptime() = %q", got)
+	}
+}
+
+func TestRenderGame(t *testing.T) {
+	stream := &fakeStreamInfo{status: StreamStatus{Game: "Just Chatting"}}
+	e := newTestEngine(stream)
+	if got := e.Render("Playing ${game} (${uptime})", Context{}); got != "Playing Just Chatting (offline)" {
+		t.Errorf("Render() = %q", got)
+	}
+	if stream.calls != 1 {
+		t.Errorf("Expected a single stream lookup per render, got %d", stream.calls)
+	}
+
+	if got := newTestEngine(nil).Render("${game}", Context{}); got != "unknown" {
+		t.Errorf("Render() = %q, want unknown without stream info", got)
+	}
+}
+
+func TestRenderRandomPick(t *testing.T) {
+	e := newTestEngine(nil)
+	e.pick = func(n int) int { return n - 1 }
+
+	if got := e.Render("I choose ${random.pick rock, paper , scissors}", Context{}); got != "I choose scissors" {
+		t.Errorf("Render() = %q", got)
+	}
+	if got := e.Render("[${random.pick}]", Context{}); got != "[]" {
+		t.Errorf("Render() = %q, want empty pick", got)
+	}
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strings"
 
 	"gopkg.in/yaml.v2"
 )
@@ -12,6 +13,7 @@ type Config struct {
 	Database DatabaseConfig `yaml:"database"`
 	Twitch   TwitchConfig   `yaml:"twitch"`
 	YouTube  YouTubeConfig  `yaml:"youtube"`
+	Commands CommandsConfig `yaml:"commands"`
 }
 
 // ServerConfig defines HTTP server settings.
@@ -23,16 +25,21 @@ type ServerConfig struct {
 	PathPrefix    string `yaml:"path_prefix"`
 	WebsocketPath string `yaml:"websocket_path"`
 	OverlayVolume int    `yaml:"overlay_volume"` // Added for volume control
+	// HistoryRetention is how long (minutes) broadcast events are kept for replay. 0 disables history.
+	HistoryRetention int `yaml:"history_retention"`
 }
 
 // DatabaseConfig defines PostgreSQL connection settings.
 type DatabaseConfig struct {
+	Driver   string `yaml:"driver"` // "postgres" (default) or "memory"
 	Host     string `yaml:"host"`
 	Port     int    `yaml:"port"`
 	User     string `yaml:"user"`
 	Password string `yaml:"password"`
 	DBName   string `yaml:"dbname"`
 	SSLMode  string `yaml:"sslmode"`
+	// AutoMigrate applies pending schema migrations when the connection opens.
+	AutoMigrate bool `yaml:"auto_migrate"`
 }
 
 // TwitchConfig defines API credentials and webhook settings.
@@ -44,6 +51,72 @@ type TwitchConfig struct {
 	UserAccessToken string           `yaml:"user_access_token"`
 	WebhookSecret   string           `yaml:"webhook_secret"`
 	Chat            TwitchChatConfig `yaml:"chat"`
+	// PersistMessageIDs stores seen EventSub message IDs in the DB so retries are de-duplicated across restarts.
+	PersistMessageIDs bool `yaml:"persist_message_ids"`
+	// EventSubTransport selects "webhook" (default, needs a public base_url) or "websocket".
+	EventSubTransport string `yaml:"eventsub_transport"`
+	// EventSubWebSocketURL overrides the EventSub WebSocket endpoint (e.g. a local mock server).
+	EventSubWebSocketURL string `yaml:"eventsub_websocket_url"`
+	// Rewards binds Channel Points rewards to actions.
+	Rewards []RewardConfig `yaml:"rewards"`
+	// GoLiveMessage is posted in chat when the stream goes online (supports ${channel}). Empty disables it.
+	GoLiveMessage string `yaml:"go_live_message"`
+	// Channels lists the monitored broadcasters. When empty, channel_name and chat.channel_to_join are used.
+	Channels []TwitchChannelConfig `yaml:"channels"`
+}
+
+// TwitchChannelConfig is one monitored broadcaster.
+type TwitchChannelConfig struct {
+	Name     string   `yaml:"name"`      // Broadcaster login
+	Events   []string `yaml:"events"`    // Enabled event groups (e.g. "follow", "raid", "poll"); empty enables all
+	JoinChat bool     `yaml:"join_chat"` // Join this channel's chat with the bot
+}
+
+// MonitoredChannels returns the channels to monitor, falling back to the single channel_name.
+func (t TwitchConfig) MonitoredChannels() []TwitchChannelConfig {
+	if len(t.Channels) > 0 {
+		return t.Channels
+	}
+	if t.ChannelName == "" {
+		return nil
+	}
+	return []TwitchChannelConfig{{Name: t.ChannelName}}
+}
+
+// PrimaryChannel returns the first monitored channel: the owner of user_access_token, whose live state drives YouTube.
+func (t TwitchConfig) PrimaryChannel() string {
+	channels := t.MonitoredChannels()
+	if len(channels) == 0 {
+		return ""
+	}
+	return strings.ToLower(channels[0].Name)
+}
+
+// ChatChannels returns the channels the bot joins, falling back to chat.channel_to_join.
+func (t TwitchConfig) ChatChannels() []string {
+	if len(t.Channels) == 0 {
+		if t.Chat.ChannelToJoin == "" {
+			return nil
+		}
+		return []string{t.Chat.ChannelToJoin}
+	}
+
+	var joins []string
+	for _, ch := range t.Channels {
+		if ch.JoinChat {
+			joins = append(joins, ch.Name)
+		}
+	}
+	return joins
+}
+
+// RewardConfig maps a Channel Points reward (by ID or title) to an action.
+type RewardConfig struct {
+	ID      string `yaml:"id"`
+	Title   string `yaml:"title"`
+	Action  string `yaml:"action"`  // "media", "alert" or "chat"
+	File    string `yaml:"file"`    // Media path relative to static/chat (media action)
+	Message string `yaml:"message"` // Alert/chat text, supports ${user} and ${input}
 }
 
 // TwitchChatConfig defines IRC bot credentials.
@@ -54,12 +127,52 @@ type TwitchChatConfig struct {
 	CommandCooldown int    `yaml:"command_cooldown"`
 }
 
+// CommandsConfig holds chat command settings shared by Twitch and YouTube.
+type CommandsConfig struct {
+	GlobalCooldown  int  `yaml:"global_cooldown"`  // Seconds between any two commands in a channel (0 = off)
+	CommandCooldown int  `yaml:"command_cooldown"` // Seconds between uses of the same command (0 = twitch.chat.command_cooldown)
+	UserCooldown    int  `yaml:"user_cooldown"`    // Seconds before the same user can repeat a command (0 = off)
+	ModBypass       bool `yaml:"mod_bypass"`       // Broadcaster and moderators ignore cooldowns
+	// CooldownNotice tells users how long to wait: "reply" (chat), "whisper" (Twitch only, sent from the primary broadcaster account) or empty.
+	CooldownNotice string `yaml:"cooldown_notice"`
+	// ReloadInterval is how often (seconds) static/chat is polled for new or removed files. 0 = 10s, negative disables.
+	ReloadInterval int `yaml:"reload_interval"`
+	// AnnounceChanges posts added and removed media commands in chat after a reload.
+	AnnounceChanges bool `yaml:"announce_changes"`
+	// Roles adds or overrides the named roles used by static/chat folders and text commands.
+	Roles []RoleConfig `yaml:"roles"`
+}
+
+// RoleConfig maps platform attributes to a named role. A role without any condition is open to everyone.
+type RoleConfig struct {
+	Name    string            `yaml:"name"`
+	Folder  string            `yaml:"folder"` // static/chat subfolder (defaults to the name)
+	Twitch  RoleTwitchConfig  `yaml:"twitch"`
+	YouTube RoleYouTubeConfig `yaml:"youtube"`
+}
+
+// RoleTwitchConfig lists the Twitch conditions of a role. Every set condition must hold.
+type RoleTwitchConfig struct {
+	Badges     []string `yaml:"badges"`      // Any of these badges, e.g. subscriber, founder, vip, moderator
+	MinMonths  int      `yaml:"min_months"`  // Subscribed for at least this many months (badge-info)
+	MinTier    int      `yaml:"min_tier"`    // Subscription tier 1-3
+	FollowDays int      `yaml:"follow_days"` // Followed for at least this many days (Helix lookup)
+}
+
+// RoleYouTubeConfig lists the YouTube flags of a role. Any set flag grants it.
+type RoleYouTubeConfig struct {
+	Sponsor   bool `yaml:"sponsor"` // Channel members
+	Moderator bool `yaml:"moderator"`
+	Owner     bool `yaml:"owner"`
+}
+
 // YouTubeConfig defines API credentials for YouTube.
 type YouTubeConfig struct {
-	APIKey          string           `yaml:"api_key"`
-	ChannelID       string           `yaml:"channel_id"`
-	PollingInterval int              `yaml:"polling_interval"`
-	Monitor         MonitoringConfig `yaml:"monitor"`
+	APIKey             string           `yaml:"api_key"`
+	ChannelID          string           `yaml:"channel_id"`
+	PollingInterval    int              `yaml:"polling_interval"`
+	MaxPollingInterval int              `yaml:"max_polling_interval"` // Cap on the server polling hint (seconds, 0 = 60, not below PollingInterval)
+	Monitor            MonitoringConfig `yaml:"monitor"`
 }
 
 // MonitoringConfig holds lists of IDs to monitor.
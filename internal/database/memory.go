@@ -0,0 +1,232 @@
+package database
+
+import (
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a process-local Store used for tests and local runs without PostgreSQL.
+type MemoryStore struct {
+	mu            sync.RWMutex
+	credentials   map[string]TwitchCredentials
+	subscriptions map[string]TwitchSubscription
+	youtubeStates map[string]YouTubeState
+	eventHistory  []EventRecord // Ordered by Seq
+	eventSubSeen  map[string]time.Time
+	textCommands  map[string]TextCommand
+	counters      map[string]int64
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		credentials:   make(map[string]TwitchCredentials),
+		subscriptions: make(map[string]TwitchSubscription),
+		youtubeStates: make(map[string]YouTubeState),
+		eventSubSeen:  make(map[string]time.Time),
+		textCommands:  make(map[string]TextCommand),
+		counters:      make(map[string]int64),
+	}
+}
+
+// Close is a no-op for the in-memory store.
+func (m *MemoryStore) Close() {}
+
+func (m *MemoryStore) GetTwitchCredentials(userID string) (*TwitchCredentials, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	creds, ok := m.credentials[userID]
+	if !ok {
+		return &TwitchCredentials{UserID: userID}, sql.ErrNoRows
+	}
+	return &creds, nil
+}
+
+func (m *MemoryStore) UpsertTwitchCredentials(creds *TwitchCredentials) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.credentials[creds.UserID] = *creds
+	return nil
+}
+
+func (m *MemoryStore) GetSubscription(userID, eventType string) (*TwitchSubscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, sub := range m.subscriptions {
+		if sub.UserID == userID && sub.EventType == eventType {
+			s := sub
+			return &s, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MemoryStore) CreateSubscription(sub *TwitchSubscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.subscriptions[sub.ID] = *sub
+	return nil
+}
+
+func (m *MemoryStore) DeleteSubscription(subscriptionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.subscriptions, subscriptionID)
+	return nil
+}
+
+func (m *MemoryStore) GetYouTubeState(channelID string) (*YouTubeState, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	state, ok := m.youtubeStates[channelID]
+	if !ok {
+		return &YouTubeState{ChannelID: channelID}, sql.ErrNoRows
+	}
+	return &state, nil
+}
+
+func (m *MemoryStore) UpsertYouTubeState(state *YouTubeState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.youtubeStates[state.ChannelID] = *state
+	return nil
+}
+
+func (m *MemoryStore) AppendEvent(rec *EventRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.eventHistory = append(m.eventHistory, *rec)
+	return nil
+}
+
+func (m *MemoryStore) GetEventsSince(seq int64, limit int) ([]EventRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var records []EventRecord
+	for _, rec := range m.eventHistory {
+		if rec.Seq <= seq {
+			continue
+		}
+		if len(records) >= limit {
+			break
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+func (m *MemoryStore) GetLastEventSeq() (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.eventHistory) == 0 {
+		return 0, nil
+	}
+	return m.eventHistory[len(m.eventHistory)-1].Seq, nil
+}
+
+func (m *MemoryStore) PruneEvents(before time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := m.eventHistory[:0]
+	for _, rec := range m.eventHistory {
+		if !rec.CreatedAt.Before(before) {
+			kept = append(kept, rec)
+		}
+	}
+	m.eventHistory = kept
+	return nil
+}
+
+func (m *MemoryStore) HasEventSubMessage(messageID string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.eventSubSeen[messageID]
+	return ok, nil
+}
+
+func (m *MemoryStore) MarkEventSubMessage(messageID string, receivedAt time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.eventSubSeen[messageID]; ok {
+		return false, nil
+	}
+	m.eventSubSeen[messageID] = receivedAt
+	return true, nil
+}
+
+func (m *MemoryStore) PruneEventSubMessages(before time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, receivedAt := range m.eventSubSeen {
+		if receivedAt.Before(before) {
+			delete(m.eventSubSeen, id)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) GetTextCommand(name string) (*TextCommand, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	cmd, ok := m.textCommands[name]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &cmd, nil
+}
+
+func (m *MemoryStore) ListTextCommands() ([]TextCommand, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	cmds := make([]TextCommand, 0, len(m.textCommands))
+	for _, cmd := range m.textCommands {
+		cmds = append(cmds, cmd)
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return cmds, nil
+}
+
+func (m *MemoryStore) UpsertTextCommand(cmd *TextCommand) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.textCommands[cmd.Name] = *cmd
+	return nil
+}
+
+func (m *MemoryStore) DeleteTextCommand(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.textCommands[name]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(m.textCommands, name)
+	return nil
+}
+
+func (m *MemoryStore) IncrementCommandCounter(name string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.counters[name]++
+	return m.counters[name], nil
+}
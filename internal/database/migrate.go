@@ -0,0 +1,250 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationsTable tracks which schema versions have been applied.
+const migrationsTable = "schema_migrations"
+
+// migrationLockKey is the pg_advisory_lock key serializing migrations across instances.
+const migrationLockKey int64 = 0x564c585f4d494752 // "VLX_MIGR"
+
+// Migration is a single versioned schema change with its forward and rollback SQL.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// loadMigrations reads the embedded migration files and returns them sorted by version.
+// Files must be named <version>_<name>.up.sql and <version>_<name>.down.sql.
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		filename := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(filename, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(filename, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(filename, "."+direction+".sql")
+		versionStr, name, found := strings.Cut(base, "_")
+		if !found {
+			return nil, fmt.Errorf("invalid migration filename %q", filename)
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %q", filename)
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join("migrations", filename))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %w", filename, err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration version %d has conflicting names %q and %q", version, m.Name, name)
+		}
+
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// ensureMigrationsTable creates the version tracking table if needed.
+func (db *DB) ensureMigrationsTable() error {
+	query := `
+		CREATE TABLE IF NOT EXISTS ` + migrationsTable + ` (
+			version    INTEGER PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL
+		)
+	`
+	_, err := db.sql.Exec(query)
+	return err
+}
+
+// appliedVersions returns the set of migration versions already recorded in the DB.
+func (db *DB) appliedVersions() (map[int]bool, error) {
+	rows, err := db.sql.Query(`SELECT version FROM ` + migrationsTable)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// withMigrationLock runs fn while holding a session-level advisory lock, so two instances
+// starting together do not apply the same migration twice.
+func (db *DB) withMigrationLock(fn func() error) error {
+	ctx := context.Background()
+	conn, err := db.sql.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to reserve migration connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, migrationLockKey); err != nil {
+			db.logger.Warn("Failed to release migration lock", zap.Error(err))
+		}
+	}()
+
+	return fn()
+}
+
+// Migrate applies every pending forward migration in version order.
+func (db *DB) Migrate() error {
+	return db.withMigrationLock(db.migrate)
+}
+
+func (db *DB) migrate() error {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return err
+	}
+	if err := db.ensureMigrationsTable(); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+	applied, err := db.appliedVersions()
+	if err != nil {
+		return fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	for _, m := range migrations {
+		if applied[m.Version] {
+			continue
+		}
+		if err := db.applyMigration(m, true); err != nil {
+			return err
+		}
+		db.logger.Info("Migration applied", zap.Int("version", m.Version), zap.String("name", m.Name))
+	}
+	return nil
+}
+
+// Rollback reverts the most recent applied migrations, up to the given number of steps.
+func (db *DB) Rollback(steps int) error {
+	if steps <= 0 {
+		return errors.New("rollback steps must be positive")
+	}
+	return db.withMigrationLock(func() error { return db.rollback(steps) })
+}
+
+func (db *DB) rollback(steps int) error {
+
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return err
+	}
+	if err := db.ensureMigrationsTable(); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+	applied, err := db.appliedVersions()
+	if err != nil {
+		return fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+		m := migrations[i]
+		if !applied[m.Version] {
+			continue
+		}
+		if err := db.applyMigration(m, false); err != nil {
+			return err
+		}
+		db.logger.Info("Migration rolled back", zap.Int("version", m.Version), zap.String("name", m.Name))
+		steps--
+	}
+	return nil
+}
+
+// applyMigration runs one migration step and records it inside a single transaction.
+func (db *DB) applyMigration(m Migration, up bool) error {
+	tx, err := db.sql.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d: %w", m.Version, err)
+	}
+
+	script := m.Down
+	if up {
+		script = m.Up
+	}
+	if _, err := tx.Exec(script); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("migration %d_%s failed: %w", m.Version, m.Name, err)
+	}
+
+	if up {
+		_, err = tx.Exec(`INSERT INTO `+migrationsTable+` (version, name, applied_at) VALUES ($1, $2, $3)`,
+			m.Version, m.Name, time.Now().UTC())
+	} else {
+		_, err = tx.Exec(`DELETE FROM `+migrationsTable+` WHERE version = $1`, m.Version)
+	}
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+	}
+
+	return tx.Commit()
+}
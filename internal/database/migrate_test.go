@@ -0,0 +1,64 @@
+package database
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadEmbeddedMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		t.Fatalf("loadMigrations failed: %v", err)
+	}
+
+	if len(migrations) == 0 {
+		t.Fatal("Expected at least one embedded migration")
+	}
+
+	// Versions must be strictly sequential starting at 1
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("Expected version %d at index %d, got %d", i+1, i, m.Version)
+		}
+	}
+}
+
+func TestLoadMigrationsValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		wantErr bool
+	}{
+		{
+			name: "Valid_Pair",
+			files: fstest.MapFS{
+				"migrations/0001_init.up.sql":   {Data: []byte("CREATE TABLE a (id INT);")},
+				"migrations/0001_init.down.sql": {Data: []byte("DROP TABLE a;")},
+			},
+		},
+		{
+			name: "Missing_Down",
+			files: fstest.MapFS{
+				"migrations/0001_init.up.sql": {Data: []byte("CREATE TABLE a (id INT);")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Invalid_Version",
+			files: fstest.MapFS{
+				"migrations/abc_init.up.sql":   {Data: []byte("SELECT 1;")},
+				"migrations/abc_init.down.sql": {Data: []byte("SELECT 1;")},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadMigrations(tt.files)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("loadMigrations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
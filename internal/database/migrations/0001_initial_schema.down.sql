@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS youtube_state;
+DROP INDEX IF EXISTS idx_twitch_subscriptions_user_event;
+DROP TABLE IF EXISTS twitch_subscriptions;
+DROP TABLE IF EXISTS twitch_credentials;
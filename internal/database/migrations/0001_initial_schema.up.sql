@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS twitch_credentials (
+    user_id       TEXT PRIMARY KEY,
+    access_token  TEXT NOT NULL,
+    refresh_token TEXT NOT NULL DEFAULT '',
+    expires_at    TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS twitch_subscriptions (
+    id         TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    status     TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_twitch_subscriptions_user_event
+    ON twitch_subscriptions (user_id, event_type);
+
+CREATE TABLE IF NOT EXISTS youtube_state (
+    channel_id      TEXT PRIMARY KEY,
+    live_chat_id    TEXT,
+    next_page_token TEXT,
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
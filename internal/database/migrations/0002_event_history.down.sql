@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_event_history_created_at;
+DROP TABLE IF EXISTS event_history;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS event_history (
+    seq        BIGINT PRIMARY KEY,
+    topic      TEXT NOT NULL,
+    payload    TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_history_created_at
+    ON event_history (created_at);
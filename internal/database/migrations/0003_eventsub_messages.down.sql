@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_eventsub_messages_received_at;
+DROP TABLE IF EXISTS eventsub_messages;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS eventsub_messages (
+    message_id  TEXT PRIMARY KEY,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_eventsub_messages_received_at
+    ON eventsub_messages (received_at);
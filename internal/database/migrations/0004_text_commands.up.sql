@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS text_commands (
+    name             TEXT PRIMARY KEY,
+    response         TEXT NOT NULL,
+    permission       TEXT NOT NULL DEFAULT 'everyone',
+    cooldown_seconds INTEGER NOT NULL DEFAULT 0,
+    updated_by       TEXT NOT NULL DEFAULT '',
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS command_counters (
+    name  TEXT PRIMARY KEY,
+    count BIGINT NOT NULL DEFAULT 0
+);
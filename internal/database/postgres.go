@@ -42,6 +42,24 @@ type YouTubeState struct {
 	UpdatedAt     time.Time
 }
 
+// EventRecord maps to the 'event_history' table
+type EventRecord struct {
+	Seq       int64
+	Topic     string
+	Payload   []byte
+	CreatedAt time.Time
+}
+
+// TextCommand maps to the 'text_commands' table
+type TextCommand struct {
+	Name            string
+	Response        string
+	Permission      string
+	CooldownSeconds int // 0 = global chat command cooldown
+	UpdatedBy       string
+	UpdatedAt       time.Time
+}
+
 // NewConnection creates, configures, and tests a new connection.
 func NewConnection(cfg config.DatabaseConfig, logger *zap.Logger) (*DB, error) {
 	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
@@ -59,7 +77,16 @@ func NewConnection(cfg config.DatabaseConfig, logger *zap.Logger) (*DB, error) {
 	}
 
 	logger.Info("Database connection established")
-	return &DB{sql: sqlDB, logger: logger}, nil
+	db := &DB{sql: sqlDB, logger: logger}
+
+	if cfg.AutoMigrate {
+		if err := db.Migrate(); err != nil {
+			sqlDB.Close()
+			return nil, fmt.Errorf("failed to migrate DB: %w", err)
+		}
+	}
+
+	return db, nil
 }
 
 // Close gracefully closes the database connection pool.
@@ -135,3 +162,132 @@ func (db *DB) UpsertYouTubeState(state *YouTubeState) error {
 	_, err := db.sql.Exec(query, state.ChannelID, state.LiveChatID, state.NextPageToken, state.UpdatedAt)
 	return err
 }
+
+func (db *DB) AppendEvent(rec *EventRecord) error {
+	query := `INSERT INTO event_history (seq, topic, payload, created_at) VALUES ($1, $2, $3, $4)`
+	_, err := db.sql.Exec(query, rec.Seq, rec.Topic, string(rec.Payload), rec.CreatedAt)
+	return err
+}
+
+func (db *DB) GetEventsSince(seq int64, limit int) ([]EventRecord, error) {
+	query := `SELECT seq, topic, payload, created_at FROM event_history WHERE seq > $1 ORDER BY seq ASC LIMIT $2`
+	rows, err := db.sql.Query(query, seq, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []EventRecord
+	for rows.Next() {
+		var rec EventRecord
+		var payload string
+		if err := rows.Scan(&rec.Seq, &rec.Topic, &payload, &rec.CreatedAt); err != nil {
+			return nil, err
+		}
+		rec.Payload = []byte(payload)
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+func (db *DB) GetLastEventSeq() (int64, error) {
+	var seq int64
+	err := db.sql.QueryRow(`SELECT COALESCE(MAX(seq), 0) FROM event_history`).Scan(&seq)
+	return seq, err
+}
+
+func (db *DB) PruneEvents(before time.Time) error {
+	_, err := db.sql.Exec(`DELETE FROM event_history WHERE created_at < $1`, before)
+	return err
+}
+
+// HasEventSubMessage reports whether a message ID was already recorded.
+func (db *DB) HasEventSubMessage(messageID string) (bool, error) {
+	var exists bool
+	err := db.sql.QueryRow(`SELECT EXISTS (SELECT 1 FROM eventsub_messages WHERE message_id = $1)`, messageID).Scan(&exists)
+	return exists, err
+}
+
+// MarkEventSubMessage records a message ID and reports whether it was not seen before.
+func (db *DB) MarkEventSubMessage(messageID string, receivedAt time.Time) (bool, error) {
+	query := `INSERT INTO eventsub_messages (message_id, received_at) VALUES ($1, $2) ON CONFLICT (message_id) DO NOTHING`
+	res, err := db.sql.Exec(query, messageID, receivedAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (db *DB) PruneEventSubMessages(before time.Time) error {
+	_, err := db.sql.Exec(`DELETE FROM eventsub_messages WHERE received_at < $1`, before)
+	return err
+}
+
+func (db *DB) GetTextCommand(name string) (*TextCommand, error) {
+	cmd := &TextCommand{Name: name}
+	query := `SELECT response, permission, cooldown_seconds, updated_by, updated_at FROM text_commands WHERE name = $1`
+	err := db.sql.QueryRow(query, name).Scan(&cmd.Response, &cmd.Permission, &cmd.CooldownSeconds, &cmd.UpdatedBy, &cmd.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+func (db *DB) ListTextCommands() ([]TextCommand, error) {
+	query := `SELECT name, response, permission, cooldown_seconds, updated_by, updated_at FROM text_commands ORDER BY name`
+	rows, err := db.sql.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cmds []TextCommand
+	for rows.Next() {
+		var cmd TextCommand
+		if err := rows.Scan(&cmd.Name, &cmd.Response, &cmd.Permission, &cmd.CooldownSeconds, &cmd.UpdatedBy, &cmd.UpdatedAt); err != nil {
+			return nil, err
+		}
+		cmds = append(cmds, cmd)
+	}
+	return cmds, rows.Err()
+}
+
+func (db *DB) UpsertTextCommand(cmd *TextCommand) error {
+	query := `
+		INSERT INTO text_commands (name, response, permission, cooldown_seconds, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (name) DO UPDATE SET
+			response = EXCLUDED.response,
+			permission = EXCLUDED.permission,
+			cooldown_seconds = EXCLUDED.cooldown_seconds,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err := db.sql.Exec(query, cmd.Name, cmd.Response, cmd.Permission, cmd.CooldownSeconds, cmd.UpdatedBy, cmd.UpdatedAt)
+	return err
+}
+
+// DeleteTextCommand removes a command, returning sql.ErrNoRows if it does not exist.
+func (db *DB) DeleteTextCommand(name string) error {
+	res, err := db.sql.Exec(`DELETE FROM text_commands WHERE name = $1`, name)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return err
+}
+
+// IncrementCommandCounter bumps the usage counter of a command and returns the new value.
+func (db *DB) IncrementCommandCounter(name string) (int64, error) {
+	query := `
+		INSERT INTO command_counters (name, count) VALUES ($1, 1)
+		ON CONFLICT (name) DO UPDATE SET count = command_counters.count + 1
+		RETURNING count
+	`
+	var count int64
+	err := db.sql.QueryRow(query, name).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"VLX_Robot/internal/config"
+
+	"go.uber.org/zap"
+)
+
+// Storage drivers selectable via config.
+const (
+	DriverPostgres = "postgres"
+	DriverMemory   = "memory"
+)
+
+// Store is the persistence contract used by the Twitch and YouTube clients.
+// Lookups of missing records return sql.ErrNoRows regardless of the backend.
+type Store interface {
+	GetTwitchCredentials(userID string) (*TwitchCredentials, error)
+	UpsertTwitchCredentials(creds *TwitchCredentials) error
+
+	GetSubscription(userID, eventType string) (*TwitchSubscription, error)
+	CreateSubscription(sub *TwitchSubscription) error
+	DeleteSubscription(subscriptionID string) error
+
+	GetYouTubeState(channelID string) (*YouTubeState, error)
+	UpsertYouTubeState(state *YouTubeState) error
+
+	AppendEvent(rec *EventRecord) error
+	GetEventsSince(seq int64, limit int) ([]EventRecord, error)
+	GetLastEventSeq() (int64, error)
+	PruneEvents(before time.Time) error
+
+	HasEventSubMessage(messageID string) (bool, error)
+	MarkEventSubMessage(messageID string, receivedAt time.Time) (bool, error)
+	PruneEventSubMessages(before time.Time) error
+
+	GetTextCommand(name string) (*TextCommand, error)
+	ListTextCommands() ([]TextCommand, error)
+	UpsertTextCommand(cmd *TextCommand) error
+	DeleteTextCommand(name string) error
+	IncrementCommandCounter(name string) (int64, error)
+
+	Close()
+}
+
+// Open returns the Store selected by cfg.Driver (PostgreSQL by default).
+func Open(cfg config.DatabaseConfig, logger *zap.Logger) (Store, error) {
+	switch cfg.Driver {
+	case "", DriverPostgres:
+		return NewConnection(cfg, logger)
+	case DriverMemory:
+		logger.Warn("Using in-memory storage. State will be lost on restart.")
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
+	}
+}
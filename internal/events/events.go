@@ -0,0 +1,133 @@
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// SchemaVersion is bumped whenever the envelope layout changes incompatibly.
+const SchemaVersion = 1
+
+// Platform identifiers
+const (
+	PlatformTwitch  = "twitch"
+	PlatformYouTube = "youtube"
+	PlatformSystem  = "system"
+)
+
+// Event type constants (kept identical to the legacy "type" values used by the overlays)
+const (
+	TypeTwitchFollow        = "twitch_follow"
+	TypeTwitchSubscribe     = "twitch_subscribe"
+	TypeTwitchResubscribe   = "twitch_resubscribe"
+	TypeTwitchGiftSub       = "twitch_gift_sub"
+	TypeTwitchCheer         = "twitch_cheer"
+	TypeTwitchRaid          = "twitch_raid"
+	TypeTwitchRedemption    = "twitch_redemption"
+	TypeTwitchHypeTrain     = "twitch_hype_train"
+	TypeTwitchPoll          = "twitch_poll"
+	TypeTwitchPrediction    = "twitch_prediction"
+	TypeTwitchStreamOnline  = "twitch_stream_online"
+	TypeTwitchStreamOffline = "twitch_stream_offline"
+	TypeYouTubeSuperChat    = "youtube_super_chat"
+	TypeYouTubeSuperSticker = "youtube_super_sticker"
+	TypeTokenHealth         = "token_health"
+	TypeSystemAlert         = "system_alert"
+	TypeSoundCommand        = "sound_command"
+	TypeEmoteWall           = "emote_wall"
+)
+
+// WebSocket topics overlays can subscribe to
+const (
+	TopicAlerts   = "alerts"
+	TopicMedia    = "media"
+	TopicEmotes   = "emotes"
+	TopicProgress = "progress"
+	TopicStatus   = "status"
+)
+
+// Topics lists every WebSocket topic.
+var Topics = []string{TopicAlerts, TopicMedia, TopicEmotes, TopicProgress, TopicStatus}
+
+// Phases of long-running events (hype trains, polls, predictions)
+const (
+	PhaseBegin    = "begin"
+	PhaseProgress = "progress"
+	PhaseLock     = "lock"
+	PhaseEnd      = "end"
+)
+
+// Actor identifies the user who caused the event.
+type Actor struct {
+	ID          string `json:"id,omitempty"`
+	Login       string `json:"login,omitempty"`
+	DisplayName string `json:"display_name"`
+}
+
+// Event is the versioned envelope broadcast to every WebSocket consumer.
+type Event struct {
+	Seq       int64       `json:"seq,omitempty"` // Assigned by the Hub at broadcast time
+	Version   int         `json:"version"`
+	Platform  string      `json:"platform"`
+	Channel   string      `json:"channel,omitempty"` // Source channel (Twitch login or YouTube channel ID)
+	Type      string      `json:"type"`
+	ID        string      `json:"id"`
+	Timestamp time.Time   `json:"timestamp"`
+	Actor     *Actor      `json:"actor,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+}
+
+// New builds an envelope stamped with the current schema version and time.
+// An empty id is replaced with a random one.
+func New(platform, eventType, id string, actor *Actor, data interface{}) *Event {
+	if id == "" {
+		id = NewID()
+	}
+	return &Event{
+		Version:   SchemaVersion,
+		Platform:  platform,
+		Type:      eventType,
+		ID:        id,
+		Timestamp: time.Now().UTC(),
+		Actor:     actor,
+		Data:      data,
+	}
+}
+
+// FromChannel tags the envelope with its source channel and returns it for chaining.
+func (e *Event) FromChannel(channel string) *Event {
+	e.Channel = channel
+	return e
+}
+
+// Topic returns the WebSocket topic the event is routed to.
+func (e *Event) Topic() string {
+	switch e.Type {
+	case TypeSoundCommand:
+		return TopicMedia
+	case TypeEmoteWall:
+		return TopicEmotes
+	case TypeTwitchHypeTrain, TypeTwitchPoll, TypeTwitchPrediction:
+		return TopicProgress
+	case TypeTwitchStreamOnline, TypeTwitchStreamOffline, TypeTokenHealth, TypeSystemAlert:
+		return TopicStatus
+	default:
+		return TopicAlerts
+	}
+}
+
+// Marshal encodes the envelope as JSON.
+func (e *Event) Marshal() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// NewID returns a random 128-bit hex identifier.
+func NewID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return hex.EncodeToString([]byte(time.Now().UTC().Format(time.RFC3339Nano)))
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,152 @@
+package events
+
+import "time"
+
+// SubscribeData is the payload of TypeTwitchSubscribe.
+type SubscribeData struct {
+	Tier   string `json:"tier"`
+	IsGift bool   `json:"is_gift"`
+}
+
+// ResubscribeData is the payload of TypeTwitchResubscribe.
+type ResubscribeData struct {
+	Tier             string `json:"tier"`
+	Message          string `json:"message"`
+	CumulativeMonths int    `json:"cumulative_months"`
+}
+
+// GiftSubData is the payload of TypeTwitchGiftSub.
+type GiftSubData struct {
+	Tier        string `json:"tier"`
+	Total       int    `json:"total"`
+	IsAnonymous bool   `json:"is_anonymous"`
+}
+
+// CheerData is the payload of TypeTwitchCheer.
+type CheerData struct {
+	Bits        int    `json:"bits"`
+	Message     string `json:"message"`
+	IsAnonymous bool   `json:"is_anonymous"`
+}
+
+// RaidData is the payload of TypeTwitchRaid. The raiding broadcaster is the Actor.
+type RaidData struct {
+	Viewers int `json:"viewers"`
+}
+
+// RedemptionData is the payload of TypeTwitchRedemption (Channel Points).
+type RedemptionData struct {
+	RewardID    string `json:"reward_id"`
+	RewardTitle string `json:"reward_title"`
+	Cost        int    `json:"cost"`
+	UserInput   string `json:"user_input"`
+	Message     string `json:"message"`
+}
+
+// Contribution is one user's share of a hype train.
+type Contribution struct {
+	UserID      string `json:"user_id"`
+	UserLogin   string `json:"user_login"`
+	DisplayName string `json:"display_name"`
+	Type        string `json:"type"` // "bits", "subscription" or "other"
+	Total       int    `json:"total"`
+}
+
+// HypeTrainData is the payload of TypeTwitchHypeTrain.
+type HypeTrainData struct {
+	Phase            string         `json:"phase"`
+	Level            int            `json:"level"`
+	Total            int            `json:"total"`
+	Progress         int            `json:"progress"`
+	Goal             int            `json:"goal"`
+	TopContributions []Contribution `json:"top_contributions"`
+	ExpiresAt        *time.Time     `json:"expires_at,omitempty"`
+	EndedAt          *time.Time     `json:"ended_at,omitempty"`
+}
+
+// PollChoice is one option of a poll with its vote tally.
+type PollChoice struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Votes int    `json:"votes"`
+}
+
+// PollData is the payload of TypeTwitchPoll.
+type PollData struct {
+	Phase   string       `json:"phase"`
+	PollID  string       `json:"poll_id"`
+	Title   string       `json:"title"`
+	Choices []PollChoice `json:"choices"`
+	Status  string       `json:"status,omitempty"` // Set on end: "completed", "terminated" or "archived"
+	EndsAt  *time.Time   `json:"ends_at,omitempty"`
+}
+
+// PredictionOutcome is one outcome of a prediction with its current stakes.
+type PredictionOutcome struct {
+	ID            string `json:"id"`
+	Title         string `json:"title"`
+	Color         string `json:"color"`
+	Users         int    `json:"users"`
+	ChannelPoints int    `json:"channel_points"`
+}
+
+// PredictionData is the payload of TypeTwitchPrediction.
+type PredictionData struct {
+	Phase            string              `json:"phase"`
+	PredictionID     string              `json:"prediction_id"`
+	Title            string              `json:"title"`
+	Outcomes         []PredictionOutcome `json:"outcomes"`
+	WinningOutcomeID string              `json:"winning_outcome_id,omitempty"`
+	Status           string              `json:"status,omitempty"` // Set on end: "resolved" or "canceled"
+	LocksAt          *time.Time          `json:"locks_at,omitempty"`
+}
+
+// StreamStatusData is the payload of TypeTwitchStreamOnline and TypeTwitchStreamOffline.
+type StreamStatusData struct {
+	Channel   string     `json:"channel"`
+	Live      bool       `json:"live"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+}
+
+// TokenHealthData is the payload of TypeTokenHealth, published when a Twitch credential changes state.
+type TokenHealthData struct {
+	UserTokenValid bool       `json:"user_token_valid"`
+	UserExpiresAt  *time.Time `json:"user_expires_at,omitempty"`
+	AppTokenValid  bool       `json:"app_token_valid"`
+	AppExpiresAt   *time.Time `json:"app_expires_at,omitempty"`
+	LastValidated  *time.Time `json:"last_validated,omitempty"`
+	Error          string     `json:"error,omitempty"`
+}
+
+// SystemAlertData is the payload of TypeSystemAlert: a problem that needs the operator's attention.
+type SystemAlertData struct {
+	Severity string `json:"severity"` // "warning" or "error"
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+// SuperChatData is the payload of TypeYouTubeSuperChat.
+type SuperChatData struct {
+	AmountString string `json:"amount_string"`
+	Message      string `json:"message"`
+	Tier         int64  `json:"tier"`
+}
+
+// SuperStickerData is the payload of TypeYouTubeSuperSticker.
+type SuperStickerData struct {
+	AmountString string `json:"amount_string"`
+	StickerAlt   string `json:"sticker_alt"`
+}
+
+// SoundCommandData is the payload of TypeSoundCommand.
+type SoundCommandData struct {
+	Command   string `json:"command"`
+	Filename  string `json:"filename"`
+	MediaType string `json:"media_type"`
+	Volume    int    `json:"volume,omitempty"` // 1-100, relative to the overlay volume
+}
+
+// EmoteWallData is the payload of TypeEmoteWall.
+type EmoteWallData struct {
+	Emotes []string `json:"emotes"`
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"html"
+	"net/http"
+	"path"
+	"time"
+
+	"VLX_Robot/internal/events"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// oauthStateCookie carries the CSRF state between the login redirect and the callback.
+	oauthStateCookie = "vlx_twitch_oauth_state"
+	oauthStateMaxAge = 10 * time.Minute
+)
+
+// handleTwitchLogin redirects the browser to the Twitch consent page.
+func (s *Server) handleTwitchLogin(w http.ResponseWriter, r *http.Request) {
+	if s.twitchClient == nil {
+		http.Error(w, "Twitch module is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	state := events.NewID()
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Value:    state,
+		Path:     s.authCookiePath(),
+		MaxAge:   int(oauthStateMaxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, s.twitchClient.AuthorizationURL(state), http.StatusFound)
+}
+
+// handleTwitchCallback exchanges the authorization code and stores the user token.
+func (s *Server) handleTwitchCallback(w http.ResponseWriter, r *http.Request) {
+	if s.twitchClient == nil {
+		http.Error(w, "Twitch module is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+	cookie, err := r.Cookie(oauthStateCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(query.Get("state"))) != 1 {
+		s.logger.Warn("OAuth callback rejected: state mismatch")
+		http.Error(w, "Invalid OAuth state, please restart the login", http.StatusBadRequest)
+		return
+	}
+
+	// The state is single-use
+	http.SetCookie(w, &http.Cookie{Name: oauthStateCookie, Path: s.authCookiePath(), MaxAge: -1})
+
+	if errCode := query.Get("error"); errCode != "" {
+		s.logger.Warn("OAuth authorization denied", zap.String("error", errCode), zap.String("description", query.Get("error_description")))
+		http.Error(w, "Authorization was denied", http.StatusForbidden)
+		return
+	}
+
+	login, err := s.twitchClient.CompleteAuthorization(query.Get("code"))
+	if err != nil {
+		s.logger.Error("OAuth code exchange failed", zap.String("login", login), zap.Error(err))
+		http.Error(w, "Authorization failed, check the server logs", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "<p>Twitch account <b>%s</b> authorized. You can close this window.</p>", html.EscapeString(login))
+}
+
+// authCookiePath scopes the state cookie to the auth routes, including any reverse-proxy prefix.
+func (s *Server) authCookiePath() string {
+	return path.Join("/", s.cfg.Server.PathPrefix, "auth/twitch")
+}
@@ -47,6 +47,9 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/static/emotes_overlay.html", func(w http.ResponseWriter, r *http.Request) {
 		s.serveTemplate(w, "emotes_overlay.html")
 	})
+	mux.HandleFunc("/static/progress_overlay.html", func(w http.ResponseWriter, r *http.Request) {
+		s.serveTemplate(w, "progress_overlay.html")
+	})
 
 	fileServer := http.FileServer(http.Dir("./static"))
 	mux.Handle("/static/", http.StripPrefix("/static/", fileServer))
@@ -58,6 +61,10 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 
 	mux.HandleFunc("/webhooks/twitch", s.twitchClient.HandleEventSubCallback)
 
+	// OAuth authorization-code flow for the broadcaster's user token
+	mux.HandleFunc("/auth/twitch/login", s.handleTwitchLogin)
+	mux.HandleFunc(twitch.CallbackPath, s.handleTwitchCallback)
+
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
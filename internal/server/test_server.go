@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"VLX_Robot/internal/events"
 	"VLX_Robot/internal/websocket"
 
 	"go.uber.org/zap"
@@ -34,32 +35,34 @@ func NewTestServer(port string, hub *websocket.Hub, logger *zap.Logger) *TestSer
 	return ts
 }
 
-// handleTestAlert processes manual alert triggers via JSON payloads.
+// handleTestAlert processes manual alert triggers sent as (partial) Event envelopes.
 func (ts *TestServer) handleTestAlert(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	var payload map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+	var evt events.Event
+	if err := json.NewDecoder(r.Body).Decode(&evt); err != nil {
 		ts.logger.Warn("Test server received invalid JSON", zap.Error(err))
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
-
-	// Marshal payload back to JSON bytes for broadcasting
-	msgBytes, err := json.Marshal(payload)
-	if err != nil {
-		ts.logger.Error("Test server JSON marshal error", zap.Error(err))
-		http.Error(w, "JSON Marshal error", http.StatusInternalServerError)
+	if evt.Type == "" {
+		http.Error(w, "Missing event type", http.StatusBadRequest)
 		return
 	}
 
+	// Fill envelope fields the caller did not provide
+	full := events.New(evt.Platform, evt.Type, evt.ID, evt.Actor, evt.Data)
+	if full.Platform == "" {
+		full.Platform = events.PlatformSystem
+	}
+
 	// Broadcast directly to the WebSocket hub
-	ts.hub.Broadcast <- msgBytes
+	ts.hub.Publish(full)
 
-	ts.logger.Info("Test alert broadcasted", zap.Any("payload", payload))
+	ts.logger.Info("Test alert broadcasted", zap.String("type", full.Type), zap.String("id", full.ID))
 
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Test alert sent via Private Test Server"))
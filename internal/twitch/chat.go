@@ -2,14 +2,18 @@ package twitch
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"VLX_Robot/internal/commands"
 	"VLX_Robot/internal/config"
+	"VLX_Robot/internal/database"
+	"VLX_Robot/internal/events"
 	"VLX_Robot/internal/websocket"
 
 	"github.com/gempir/go-twitch-irc/v4"
@@ -17,81 +21,98 @@ import (
 	"golang.org/x/time/rate"
 )
 
-// Permission constants
+// Permission constants (the built-in roles; more can be configured under commands.roles)
 const (
-	PermissionEveryone   = "everyone"   // Public/Followers
-	PermissionSubscriber = "subscriber" // Paid Subscribers
-	PermissionVIP        = "vip"        // VIP/Mods
+	PermissionEveryone   = commands.RoleEveryone   // Public/Followers
+	PermissionSubscriber = commands.RoleSubscriber // Paid Subscribers
+	PermissionVIP        = commands.RoleVIP        // VIP/Mods
 )
 
-// CommandData holds metadata for media commands
-type CommandData struct {
-	Filename   string
-	Permission string
-	MediaType  string // "audio" or "video"
-}
-
-type AudioCommandsMap map[string]CommandData
+// AudioCommandsMap maps command names to the media files found in static/chat.
+type AudioCommandsMap map[string]commands.MediaCommand
 
 // ChatClient handles Twitch IRC connection
 type ChatClient struct {
-	config           config.TwitchChatConfig
-	hub              *websocket.Hub
-	client           *twitch.Client
-	commands         AudioCommandsMap
-	lastUsage        map[string]time.Time // Tracks command cooldowns
-	cooldownDuration time.Duration        // Configured cooldown
-	logger           *zap.Logger
-	sayLimiter       *rate.Limiter // Rate limiter for outgoing chat messages
+	config     config.TwitchChatConfig
+	channels   []string // Channels the bot joins
+	hub        *websocket.Hub
+	client     *twitch.Client
+	registry   *commands.Registry // Media commands, cooldowns and usage stats (shared with YouTube)
+	roles      *commands.Roles    // Named roles required by commands (nil = built-in roles)
+	db         database.Store     // Text commands; nil disables them
+	templates  *commands.Engine   // Expands variables in text command responses (optional)
+	whisperer  Whisperer          // Optional, delivers cooldown notices as whispers
+	follows    FollowChecker      // Optional, follow dates for roles with follow_days
+	logger     *zap.Logger
+	sayLimiter *rate.Limiter // Rate limiter for outgoing chat messages
 }
 
-// ChatAlertPayload defines the JSON sent to the overlay
-type ChatAlertPayload struct {
-	Type      string `json:"type"`
-	Filename  string `json:"filename"`
-	MediaType string `json:"media_type"`
+// Whisperer sends a private message to a Twitch user.
+type Whisperer interface {
+	Whisper(toUserID, message string) error
 }
 
-type EmoteWallPayload struct {
-	Type   string   `json:"type"`
-	Emotes []string `json:"emotes"`
+// FollowChecker looks up when a user followed a channel.
+type FollowChecker interface {
+	FollowedAt(broadcasterID, userID string) (time.Time, bool, error)
 }
 
 // NewChatClient initializes the ChatClient with dependencies and rate limiters.
-func NewChatClient(cfg config.TwitchChatConfig, hub *websocket.Hub, commands AudioCommandsMap, logger *zap.Logger) *ChatClient {
-	// Set default cooldown if invalid
-	cd := cfg.CommandCooldown
-	if cd <= 0 {
-		cd = 15
-	}
-
+func NewChatClient(cfg config.TwitchChatConfig, channels []string, hub *websocket.Hub, registry *commands.Registry, db database.Store, logger *zap.Logger) *ChatClient {
 	// Initialize Rate Limiter for outgoing messages.
 	// Twitch limits: 20/30s for users, 100/30s for mods.
 	// We use a conservative bucket: 1 message per second, burst of 5.
 	limiter := rate.NewLimiter(rate.Every(time.Second), 5)
 
 	return &ChatClient{
-		config:           cfg,
-		hub:              hub,
-		commands:         commands,
-		lastUsage:        make(map[string]time.Time),
-		cooldownDuration: time.Duration(cd) * time.Second,
-		logger:           logger,
-		sayLimiter:       limiter,
+		config:     cfg,
+		channels:   channels,
+		hub:        hub,
+		registry:   registry,
+		db:         db,
+		logger:     logger,
+		sayLimiter: limiter,
 	}
 }
 
-// ScanAudioCommands recursively scans command folders to build the command map.
-func ScanAudioCommands(baseDir string, logger *zap.Logger) (AudioCommandsMap, error) {
-	commands := make(AudioCommandsMap)
+// SetWhisperer enables whispered cooldown notices (commands.cooldown_notice: "whisper").
+func (c *ChatClient) SetWhisperer(w Whisperer) {
+	c.whisperer = w
+}
+
+// SetRoles replaces the built-in roles with the configured ones.
+func (c *ChatClient) SetRoles(roles *commands.Roles) {
+	c.roles = roles
+}
 
-	folders := map[string]string{
-		"everyone":    PermissionEveryone,
-		"subscribers": PermissionSubscriber,
-		"vips":        PermissionVIP,
+// SetFollowChecker enables roles based on follow age (commands.roles[].twitch.follow_days).
+func (c *ChatClient) SetFollowChecker(f FollowChecker) {
+	c.follows = f
+}
+
+// SetTemplates enables variable expansion (${user}, ${count}, ...) in text command responses.
+func (c *ChatClient) SetTemplates(engine *commands.Engine) {
+	c.templates = engine
+}
+
+// ScanAudioCommands recursively scans the folder of every role to build the command map.
+// A subfolder (everyone/fart/) becomes one random-pick command over its files.
+// Metadata comes from a sidecar next to each file or subfolder (hello.yml, hello.yaml or hello.json)
+// or, when there is none, from the folder manifest (commands.yml). Aliases get their own entries.
+// When a name exists in several folders, the folder that sorts first wins and the others are logged as shadowed.
+func ScanAudioCommands(baseDir string, roles *commands.Roles, logger *zap.Logger) (AudioCommandsMap, error) {
+	found := make(AudioCommandsMap)
+
+	// Scan folders in name order so a name present in several folders resolves the same way on every scan
+	folders := roles.Folders()
+	names := make([]string, 0, len(folders))
+	for name := range folders {
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
-	for folderName, permission := range folders {
+	for _, folderName := range names {
+		permission := folders[folderName]
 		fullPath := filepath.Join(baseDir, folderName)
 
 		if _, err := os.Stat(fullPath); os.IsNotExist(err) {
@@ -103,41 +124,81 @@ func ScanAudioCommands(baseDir string, logger *zap.Logger) (AudioCommandsMap, er
 			logger.Warn("Could not read command folder", zap.String("path", fullPath), zap.Error(err))
 			continue
 		}
+		manifest := loadManifest(fullPath, logger)
 
 		for _, file := range files {
-			if file.IsDir() {
+			if isMetadataFile(file.Name()) {
 				continue
 			}
 
 			filename := file.Name()
-			ext := strings.ToLower(filepath.Ext(filename))
-			commandName := strings.ToLower(strings.TrimSuffix(filename, ext))
-
-			var mediaType string
-			switch ext {
-			case ".mp3", ".wav", ".ogg":
-				mediaType = "audio"
-			case ".mp4", ".webm":
-				mediaType = "video"
-			default:
-				continue
+			base := filename
+			if !file.IsDir() {
+				base = strings.TrimSuffix(filename, filepath.Ext(filename))
 			}
-
+			commandName := strings.ToLower(base)
 			relativePath := folderName + "/" + filename
 
-			if _, exists := commands[commandName]; exists {
-				logger.Warn("Duplicate command detected, skipping", zap.String("command", commandName), zap.String("path", relativePath))
+			cmd := commands.MediaCommand{
+				Name:       commandName,
+				Filename:   relativePath,
+				Permission: permission,
+			}
+			if file.IsDir() {
+				// A subfolder is a random-pick command playing one of its files
+				cmd.Clips = scanClips(filepath.Join(fullPath, filename), relativePath)
+				if len(cmd.Clips) == 0 {
+					continue
+				}
 			} else {
-				commands[commandName] = CommandData{
-					Filename:   relativePath,
-					Permission: permission,
-					MediaType:  mediaType,
+				mediaType, err := mediaTypeFor(filename)
+				if err != nil {
+					continue
 				}
+				cmd.MediaType = mediaType
+			}
+
+			if existing, exists := found[commandName]; exists {
+				logger.Warn("Duplicate command detected, skipping",
+					zap.String("command", commandName), zap.String("path", relativePath), zap.String("kept", existing.Filename))
+				continue
+			}
+
+			if meta, ok := loadSidecar(fullPath, base, logger); ok {
+				meta.apply(&cmd, logger)
+			} else if meta, ok := manifest[commandName]; ok {
+				meta.apply(&cmd, logger)
 			}
+			if !roles.Has(cmd.Permission) {
+				logger.Warn("Unknown command role, only moderators can use it", zap.String("command", commandName), zap.String("role", cmd.Permission))
+			}
+			found[commandName] = cmd
 		}
 	}
 
-	return commands, nil
+	registerAliases(found, logger)
+	return found, nil
+}
+
+// scanClips lists the media files of a random-pick folder, sorted by name.
+func scanClips(dir, relativeDir string) []commands.Clip {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var clips []commands.Clip
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		mediaType, err := mediaTypeFor(file.Name())
+		if err != nil {
+			continue
+		}
+		clips = append(clips, commands.Clip{Filename: relativeDir + "/" + file.Name(), MediaType: mediaType, Weight: 1})
+	}
+	return clips
 }
 
 // Start initiates the Twitch IRC connection.
@@ -150,10 +211,10 @@ func (c *ChatClient) Start() {
 	c.client.OnPrivateMessage(c.handlePrivateMessage)
 
 	c.client.OnConnect(func() {
-		c.logger.Info("Connected to IRC channel", zap.String("channel", c.config.ChannelToJoin))
+		c.logger.Info("Connected to IRC channels", zap.Strings("channels", c.channels))
 	})
 
-	c.client.Join(c.config.ChannelToJoin)
+	c.client.Join(c.channels...)
 
 	// Background reconnection loop
 	go func() {
@@ -179,12 +240,8 @@ func (c *ChatClient) handlePrivateMessage(message twitch.PrivateMessage) {
 		}
 
 		if len(emoteURLs) > 0 {
-			payload := EmoteWallPayload{
-				Type:   "emote_wall",
-				Emotes: emoteURLs,
-			}
-			payloadBytes, _ := json.Marshal(payload)
-			c.hub.Broadcast <- payloadBytes
+			c.hub.Publish(events.New(events.PlatformTwitch, events.TypeEmoteWall, "", chatActor(message.User),
+				events.EmoteWallData{Emotes: emoteURLs}).FromChannel(message.Channel))
 		}
 	}
 
@@ -193,46 +250,119 @@ func (c *ChatClient) handlePrivateMessage(message twitch.PrivateMessage) {
 		return
 	}
 
-	rawCommand := strings.Fields(message.Message)[0]
-	commandName := strings.ToLower(strings.TrimPrefix(rawCommand, "!"))
+	fields := strings.Fields(message.Message)
+	commandName := strings.ToLower(strings.TrimPrefix(fields[0], "!"))
 
-	// 3. LIST COMMANDS Logic (!commands)
+	// 3. LIST COMMANDS Logic (!commands, or !commands <name> for details)
 	if commandName == "commands" || commandName == "comandi" {
+		if len(fields) > 1 {
+			if details := c.commandDetails(fields[1]); details != "" {
+				c.Say(message.Channel, details)
+			}
+			return
+		}
 		c.handleListCommands(message.Channel)
 		return
 	}
 
-	// 4. MEDIA COMMAND Logic
-	cmdData, exists := c.commands[commandName]
+	// 4. TEXT COMMAND MANAGEMENT (!addcom, !editcom, !delcom)
+	switch commandName {
+	case CommandAdd, CommandEdit, CommandDelete:
+		if reply := c.manageTextCommand(message, commandName, fields[1:]); reply != "" {
+			c.Say(message.Channel, reply)
+		}
+		return
+	}
+
+	// 5. MEDIA COMMAND Logic, falling back to TEXT COMMANDS
+	cmdData, exists := c.registry.Media(commandName)
 	if !exists {
+		if response := c.textCommandResponse(message, commandName, fields[1:]); response != "" {
+			c.Say(message.Channel, response)
+		}
 		return
 	}
 
+	// Aliases share the cooldowns and stats of their command
+	commandName = cmdData.Name
+
 	// Permission check
-	if !c.hasPermission(message.User, cmdData.Permission) {
+	if !c.hasPermission(message, cmdData.Permission) {
 		return
 	}
 
-	// --- COOLDOWN CHECK ---
-	if lastUsed, ok := c.lastUsage[commandName]; ok {
-		if time.Since(lastUsed) < c.cooldownDuration {
-			c.logger.Info("Command on cooldown", zap.String("command", commandName), zap.String("user", message.User.Name))
-			return
+	if !c.checkCooldown(message, commandName, cmdData.Cooldown) {
+		return
+	}
+
+	c.logger.Info("Command triggered", zap.String("command", commandName), zap.String("channel", message.Channel), zap.String("user", message.User.Name))
+
+	c.hub.Publish(events.New(events.PlatformTwitch, events.TypeSoundCommand, message.ID, chatActor(message.User),
+		soundCommandData(cmdData, c.registry.Pick(cmdData))).FromChannel(message.Channel))
+}
+
+// checkCooldown records a command use, or reports the remaining wait to the user when it is on cooldown.
+// override replaces the default per-command cooldown when positive.
+func (c *ChatClient) checkCooldown(message twitch.PrivateMessage, commandName string, override time.Duration) bool {
+	verdict := c.registry.Use(commands.Usage{
+		Scope:      "twitch/" + message.Channel,
+		Command:    commandName,
+		User:       message.User.ID,
+		Privileged: isModerator(message.User),
+		Cooldown:   override,
+	})
+	if verdict.Allowed {
+		return true
+	}
+
+	c.logger.Info("Command on cooldown", zap.String("command", commandName), zap.String("user", message.User.Name), zap.Duration("remaining", verdict.Remaining))
+	if !verdict.Notify {
+		return false
+	}
+	notice := commands.NoticeText(message.User.DisplayName, commandName, verdict.Remaining)
+	switch c.registry.Policy().Notice {
+	case commands.NoticeReply:
+		go c.Say(message.Channel, notice)
+	case commands.NoticeWhisper:
+		if c.whisperer == nil {
+			break
 		}
+		go func() {
+			if err := c.whisperer.Whisper(message.User.ID, notice); err != nil {
+				c.logger.Warn("Failed to whisper cooldown notice", zap.String("user", message.User.Name), zap.Error(err))
+			}
+		}()
 	}
-	c.lastUsage[commandName] = time.Now()
-	// ----------------------
+	return false
+}
+
+// soundCommandData builds the chat_overlay payload of a media command playing clip.
+func soundCommandData(cmd commands.MediaCommand, clip commands.Clip) events.SoundCommandData {
+	return events.SoundCommandData{Command: cmd.Name, Filename: clip.Filename, MediaType: clip.MediaType, Volume: cmd.Volume}
+}
 
-	c.logger.Info("Command triggered", zap.String("command", commandName), zap.String("user", message.User.Name))
+// chatActor maps an IRC user to the event Actor.
+func chatActor(user twitch.User) *events.Actor {
+	return &events.Actor{ID: user.ID, Login: user.Name, DisplayName: user.DisplayName}
+}
 
-	payload := ChatAlertPayload{
-		Type:      "sound_command",
-		Filename:  cmdData.Filename,
-		MediaType: cmdData.MediaType,
+// Say sends a chat message, respecting the outgoing rate limit.
+func (c *ChatClient) Say(channel, message string) {
+	if c.client == nil {
+		return
 	}
+	if err := c.sayLimiter.Wait(context.Background()); err != nil {
+		c.logger.Warn("Rate limit exceeded for outgoing message", zap.Error(err))
+		return
+	}
+	c.client.Say(channel, message)
+}
 
-	payloadBytes, _ := json.Marshal(payload)
-	c.hub.Broadcast <- payloadBytes
+// Announce sends a message to every joined channel.
+func (c *ChatClient) Announce(message string) {
+	for _, channel := range c.channels {
+		c.Say(channel, message)
+	}
 }
 
 // handleListCommands constructs and sends the list of available commands.
@@ -243,46 +373,64 @@ func (c *ChatClient) handleListCommands(channel string) {
 		return
 	}
 
-	var everyone []string
-	var subs []string
-	var vips []string
+	media := c.registry.MediaCommands()
+	permissions := make(map[string]string, len(media))
+	labels := make(map[string]string)
+	for name, data := range media {
+		// Aliases and hidden commands stay out of the listing
+		if name != data.Name || data.Hidden {
+			continue
+		}
+		permissions[name] = data.Permission
+		if data.Cost > 0 {
+			labels[name] = fmt.Sprintf("!%s (%d pts)", name, data.Cost)
+		}
+	}
+	if c.db != nil {
+		textCommands, err := c.db.ListTextCommands()
+		if err != nil {
+			c.logger.Warn("Failed to list text commands", zap.Error(err))
+		}
+		for _, cmd := range textCommands {
+			permissions[cmd.Name] = cmd.Permission
+			delete(labels, cmd.Name)
+		}
+	}
 
-	for name, data := range c.commands {
+	groups := make(map[string][]string)
+	for name, permission := range permissions {
 		cmd := "!" + name
-		switch data.Permission {
-		case PermissionEveryone:
-			everyone = append(everyone, cmd)
-		case PermissionSubscriber:
-			subs = append(subs, cmd)
-		case PermissionVIP:
-			vips = append(vips, cmd)
+		if label, ok := labels[name]; ok {
+			cmd = label
 		}
+		groups[permission] = append(groups[permission], cmd)
 	}
 
-	sort.Strings(everyone)
-	sort.Strings(subs)
-	sort.Strings(vips)
-
-	var sb strings.Builder
-
-	if len(everyone) > 0 {
-		sb.WriteString(strings.Join(everyone, ", "))
+	// Built-in roles first, then the configured ones by name
+	order := []string{PermissionEveryone, PermissionSubscriber, PermissionVIP}
+	for _, role := range c.roles.Names() {
+		if role != PermissionEveryone && role != PermissionSubscriber && role != PermissionVIP {
+			order = append(order, role)
+		}
 	}
 
-	if len(subs) > 0 {
-		if sb.Len() > 0 {
-			sb.WriteString(" / ")
+	var sb strings.Builder
+	for _, role := range order {
+		names := groups[role]
+		if len(names) == 0 {
+			continue
 		}
-		sb.WriteString("Subscribers: ")
-		sb.WriteString(strings.Join(subs, ", "))
-	}
+		sort.Strings(names)
 
-	if len(vips) > 0 {
 		if sb.Len() > 0 {
 			sb.WriteString(" / ")
 		}
-		sb.WriteString("Vips: ")
-		sb.WriteString(strings.Join(vips, ", "))
+		if role != PermissionEveryone {
+			// Labelled after the folder: "Subscribers: ", "Vips: "
+			folder := c.roles.Folder(role)
+			sb.WriteString(strings.ToUpper(folder[:1]) + folder[1:] + ": ")
+		}
+		sb.WriteString(strings.Join(names, ", "))
 	}
 
 	response := sb.String()
@@ -292,28 +440,89 @@ func (c *ChatClient) handleListCommands(channel string) {
 	c.client.Say(channel, response)
 }
 
-// hasPermission checks Twitch badges against required level
-func (c *ChatClient) hasPermission(user twitch.User, requiredLevel string) bool {
-	// Broadcasters and Mods have all permissions
-	if _, ok := user.Badges["broadcaster"]; ok {
-		return true
+// commandDetails describes a media command for !commands <name>. Hidden and unknown commands return "".
+func (c *ChatClient) commandDetails(name string) string {
+	cmd, ok := c.registry.Media(normalizeCommandName(name))
+	if !ok || cmd.Hidden {
+		return ""
 	}
-	if _, ok := user.Badges["moderator"]; ok {
-		return true
+
+	parts := []string{"!" + cmd.Name}
+	if cmd.Description != "" {
+		parts[0] += ": " + cmd.Description
+	}
+	if len(cmd.Clips) > 0 {
+		parts = append(parts, fmt.Sprintf("Random: %d clips", len(cmd.Clips)))
+	}
+	if len(cmd.Aliases) > 0 {
+		parts = append(parts, "Aliases: !"+strings.Join(cmd.Aliases, ", !"))
+	}
+	if cmd.Cooldown > 0 {
+		parts = append(parts, fmt.Sprintf("Cooldown: %ds", int(cmd.Cooldown/time.Second)))
+	}
+	if cmd.Cost > 0 {
+		parts = append(parts, fmt.Sprintf("Cost: %d pts", cmd.Cost))
 	}
+	return strings.Join(parts, " | ")
+}
 
-	switch requiredLevel {
-	case PermissionEveryone:
-		return true
-	case PermissionSubscriber:
-		// Checks for sub or founder badge
-		_, isSub := user.Badges["subscriber"]
-		_, isFounder := user.Badges["founder"]
-		return isSub || isFounder
-	case PermissionVIP:
-		_, isVIP := user.Badges["vip"]
-		return isVIP
-	default:
-		return false
+// hasPermission checks the user's badges, subscription and follow age against the required role.
+func (c *ChatClient) hasPermission(message twitch.PrivateMessage, role string) bool {
+	return c.roles.Allows(role, c.viewer(message))
+}
+
+// viewer maps an IRC message to the attributes roles are matched against.
+func (c *ChatClient) viewer(message twitch.PrivateMessage) commands.Viewer {
+	v := commands.Viewer{
+		Privileged: isModerator(message.User),
+		Badges:     message.User.Badges,
+		SubMonths:  subMonths(message.Tags["badge-info"]),
+	}
+	if c.follows != nil && message.RoomID != "" {
+		v.FollowedAt = func() (time.Time, bool) {
+			return c.followedAt(message.RoomID, message.User.ID, message.User.Name)
+		}
+	}
+	return v
+}
+
+// followedAt looks up the follow age without holding up chat for more than followLookupTimeout.
+// A slower lookup keeps running in the background and fills the cache, so the viewer passes on a later try.
+func (c *ChatClient) followedAt(broadcasterID, userID, name string) (time.Time, bool) {
+	type followResult struct {
+		since     time.Time
+		following bool
+		err       error
+	}
+	result := make(chan followResult, 1)
+	go func() {
+		since, following, err := c.follows.FollowedAt(broadcasterID, userID)
+		result <- followResult{since, following, err}
+	}()
+
+	select {
+	case r := <-result:
+		if r.err != nil {
+			c.logger.Warn("Follow lookup failed", zap.String("user", name), zap.Error(r.err))
+			return time.Time{}, false
+		}
+		return r.since, r.following
+	case <-time.After(followLookupTimeout):
+		c.logger.Warn("Follow lookup timed out, treating as not following for now", zap.String("user", name))
+		return time.Time{}, false
+	}
+}
+
+// subMonths reads the subscription months from the badge-info tag ("subscriber/14" or "founder/20").
+func subMonths(badgeInfo string) int {
+	for _, info := range strings.Split(badgeInfo, ",") {
+		name, value, ok := strings.Cut(info, "/")
+		if !ok || (name != "subscriber" && name != "founder") {
+			continue
+		}
+		if months, err := strconv.Atoi(value); err == nil {
+			return months
+		}
 	}
+	return 0
 }
@@ -1,9 +1,17 @@
 package twitch
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+	"time"
+
+	"VLX_Robot/internal/commands"
+	"VLX_Robot/internal/config"
+	"VLX_Robot/internal/database"
+	"VLX_Robot/internal/websocket"
 
 	"github.com/gempir/go-twitch-irc/v4"
 	"go.uber.org/zap"
@@ -39,7 +47,7 @@ func TestScanAudioCommands(t *testing.T) {
 
 	// 3. Run Scan
 	logger := zap.NewNop()
-	cmds, err := ScanAudioCommands(tmpDir, logger)
+	cmds, err := ScanAudioCommands(tmpDir, commands.DefaultRoles(), logger)
 	if err != nil {
 		t.Fatalf("ScanAudioCommands failed: %v", err)
 	}
@@ -87,9 +95,39 @@ func TestHasPermission(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			user := twitch.User{Badges: tt.userBadges}
-			if got := client.hasPermission(user, tt.requiredLevel); got != tt.expected {
+			if got := client.hasPermission(twitch.PrivateMessage{User: user}, tt.requiredLevel); got != tt.expected {
 				t.Errorf("hasPermission() = %v, want %v", got, tt.expected)
 			}
 		})
 	}
 }
+
+func TestHandlePrivateMessageConcurrent(t *testing.T) {
+	logger := zap.NewNop()
+	hub := websocket.NewHub(logger)
+	go hub.Run()
+
+	registry := commands.NewRegistry(AudioCommandsMap{"hello": {Filename: "everyone/hello.mp3", Permission: PermissionEveryone, MediaType: "audio"}},
+		commands.NewCooldowns(commands.CooldownPolicy{User: time.Minute}))
+	store := database.NewMemoryStore()
+	store.UpsertTextCommand(&database.TextCommand{Name: "discord", Response: "discord.gg/x", Permission: PermissionEveryone})
+	c := NewChatClient(config.TwitchChatConfig{}, nil, hub, registry, store, logger)
+
+	// go-twitch-irc callbacks and YouTube polling may reach the shared state at the same time
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			user := twitch.User{ID: fmt.Sprintf("%d", i), Name: fmt.Sprintf("viewer%d", i), Badges: map[string]int{}}
+			for _, text := range []string{"!hello", "!discord", "!hello again"} {
+				c.handlePrivateMessage(twitch.PrivateMessage{Channel: "streamer", User: user, Message: text})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if stats := registry.Stats("hello"); stats.Uses != 20 || stats.Rejected != 20 {
+		t.Errorf("Expected one use per viewer, got %+v", stats)
+	}
+}
@@ -0,0 +1,133 @@
+package twitch
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"VLX_Robot/internal/database"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// maxMessageAge is the replay-protection window recommended by Twitch.
+	maxMessageAge = 10 * time.Minute
+	// maxSeenMessages bounds the in-memory de-duplication cache.
+	maxSeenMessages = 10000
+)
+
+// seenMessage is an entry of the de-duplication FIFO.
+type seenMessage struct {
+	id         string
+	receivedAt time.Time
+}
+
+// messageDeduplicator remembers EventSub message IDs for a time window so retries are processed once.
+type messageDeduplicator struct {
+	mu         sync.Mutex
+	window     time.Duration
+	maxEntries int
+	seen       map[string]*list.Element
+	order      *list.List // Oldest first
+	store      database.Store
+	lastPrune  time.Time
+	now        func() time.Time
+	logger     *zap.Logger
+}
+
+// newMessageDeduplicator creates a deduplicator. A nil store keeps state in memory only.
+func newMessageDeduplicator(window time.Duration, maxEntries int, store database.Store, logger *zap.Logger) *messageDeduplicator {
+	return &messageDeduplicator{
+		window:     window,
+		maxEntries: maxEntries,
+		seen:       make(map[string]*list.Element),
+		order:      list.New(),
+		store:      store,
+		now:        time.Now,
+		logger:     logger,
+	}
+}
+
+// isDuplicate reports whether the message ID was already handled within the window.
+// It does not record the ID: call record once the message has been processed.
+func (d *messageDeduplicator) isDuplicate(messageID string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now().UTC()
+	d.evictExpired(now)
+
+	if _, ok := d.seen[messageID]; ok {
+		return true
+	}
+
+	// Check the persistent store so retries survive a restart
+	if d.store != nil {
+		if now.Sub(d.lastPrune) > d.window {
+			if err := d.store.PruneEventSubMessages(now.Add(-d.window)); err != nil {
+				d.logger.Warn("Failed to prune EventSub message IDs", zap.Error(err))
+			}
+			d.lastPrune = now
+		}
+
+		exists, err := d.store.HasEventSubMessage(messageID)
+		if err != nil {
+			d.logger.Warn("Failed to look up EventSub message ID", zap.String("id", messageID), zap.Error(err))
+		} else if exists {
+			d.remember(messageID, now)
+			return true
+		}
+	}
+	return false
+}
+
+// record marks the message ID as handled so later retries are ignored.
+func (d *messageDeduplicator) record(messageID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now().UTC()
+	if _, ok := d.seen[messageID]; ok {
+		return
+	}
+
+	if d.store != nil {
+		if _, err := d.store.MarkEventSubMessage(messageID, now); err != nil {
+			d.logger.Warn("Failed to persist EventSub message ID", zap.String("id", messageID), zap.Error(err))
+		}
+	}
+	d.remember(messageID, now)
+}
+
+// remember adds an ID to the cache, evicting the oldest entry when full.
+func (d *messageDeduplicator) remember(messageID string, now time.Time) {
+	if d.order.Len() >= d.maxEntries {
+		oldest := d.order.Front()
+		d.order.Remove(oldest)
+		delete(d.seen, oldest.Value.(seenMessage).id)
+	}
+	d.seen[messageID] = d.order.PushBack(seenMessage{id: messageID, receivedAt: now})
+}
+
+// evictExpired drops entries older than the window.
+func (d *messageDeduplicator) evictExpired(now time.Time) {
+	cutoff := now.Add(-d.window)
+	for e := d.order.Front(); e != nil; e = d.order.Front() {
+		entry := e.Value.(seenMessage)
+		if !entry.receivedAt.Before(cutoff) {
+			return
+		}
+		d.order.Remove(e)
+		delete(d.seen, entry.id)
+	}
+}
+
+// isStaleTimestamp reports whether an EventSub timestamp header is missing, malformed or older than maxMessageAge.
+func isStaleTimestamp(header string, now time.Time) bool {
+	ts, err := time.Parse(time.RFC3339Nano, header)
+	if err != nil {
+		return true
+	}
+	return now.Sub(ts) > maxMessageAge
+}
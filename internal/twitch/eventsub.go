@@ -10,10 +10,14 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"VLX_Robot/internal/config"
 	"VLX_Robot/internal/database"
+	"VLX_Robot/internal/events"
 	"VLX_Robot/internal/websocket"
 
 	"github.com/nicklaw5/helix/v2"
@@ -28,6 +32,13 @@ const (
 	EventSubSubMessage = "channel.subscription.message"
 	EventSubCheer      = "channel.cheer"
 	EventSubRaid       = "channel.raid"
+	EventSubRedemption = "channel.channel_points_custom_reward_redemption.add"
+)
+
+// EventSub transport modes
+const (
+	TransportWebhook   = "webhook"
+	TransportWebSocket = "websocket"
 )
 
 // Client manages Twitch API interactions and EventSub webhooks.
@@ -35,16 +46,49 @@ type Client struct {
 	config      config.TwitchConfig
 	helix       *helix.Client
 	hub         *websocket.Hub
-	db          *database.DB
+	db          database.Store
+	dedup       *messageDeduplicator
+	chat        ChatSender                            // Optional, used by chat reward actions
+	mediaDir    string                                // Root of the media command library (static/chat)
+	userID      string                                // Owner of the user access token (primary monitored channel)
+	channels    map[string]config.TwitchChannelConfig // Monitored channels by lowercase login
+	whispers    bool                                  // Request user:manage:whispers (cooldown notices)
 	selfBaseURL string
 	logger      *zap.Logger
+
+	liveMu          sync.RWMutex
+	live            map[string]bool // Channel login -> live
+	liveListeners   []LiveListener
+	liveChanges     chan liveChange // Transitions waiting for the listeners, drained in order
+	streamMu        sync.Mutex      // Guards streamCache
+	streamCache     map[string]cachedStreamStatus
+	followMu        sync.Mutex // Guards followCache and followWait
+	followCache     map[string]cachedFollow
+	followWait      map[string]chan struct{}     // Follow lookups in flight, closed when done
+	rewardMu        sync.Mutex                   // Guards manageable and unmanagedWarned
+	manageable      map[string]manageableRewards // Broadcaster user ID -> rewards this Client ID may update
+	unmanagedWarned map[string]bool              // Reward IDs already reported as not manageable
+	wsMu            sync.Mutex                   // Guards wsSessionID
+	wsSessionID     string                       // Current EventSub WebSocket session, follows session_reconnect
+	tokenMu         sync.Mutex                   // Guards the token manager state
+	userTokens      map[string]*tokenState       // Broadcaster user ID -> user token state
+	appToken        tokenState
+	lastValidated   time.Time
+	lastHealth      events.TokenHealthData
 }
 
 // NewClient initializes the Twitch client with database-backed token management.
-func NewClient(cfg config.TwitchConfig, monitoringChannels []string, baseURL string, hub *websocket.Hub, db *database.DB, logger *zap.Logger) (*Client, error) {
+func NewClient(cfg config.TwitchConfig, monitoringChannels []string, baseURL string, hub *websocket.Hub, db database.Store, logger *zap.Logger) (*Client, error) {
+	// The redirect URI must match the one registered for the app on dev.twitch.tv
+	redirectURI := cfg.RedirectURI
+	if redirectURI == "" && baseURL != "" {
+		redirectURI = baseURL + CallbackPath
+	}
+
 	helixClient, err := helix.NewClient(&helix.Options{
 		ClientID:     cfg.ClientID,
 		ClientSecret: cfg.ClientSecret,
+		RedirectURI:  redirectURI,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create helix client: %w", err)
@@ -57,14 +101,34 @@ func NewClient(cfg config.TwitchConfig, monitoringChannels []string, baseURL str
 	}
 	helixClient.SetAppAccessToken(appToken.Data.AccessToken)
 
+	// Seen message IDs are optionally persisted so retries are detected across restarts
+	var dedupStore database.Store
+	if cfg.PersistMessageIDs {
+		dedupStore = db
+	}
+
 	c := &Client{
 		helix:       helixClient,
 		db:          db,
+		dedup:       newMessageDeduplicator(maxMessageAge, maxSeenMessages, dedupStore, logger),
+		mediaDir:    filepath.Join("static", "chat"),
 		hub:         hub,
 		config:      cfg,
 		selfBaseURL: baseURL,
 		logger:      logger,
+		live:        make(map[string]bool),
+		userTokens:  make(map[string]*tokenState),
+		channels:    make(map[string]config.TwitchChannelConfig),
+	}
+	for _, ch := range cfg.MonitoredChannels() {
+		c.channels[strings.ToLower(ch.Name)] = ch
+		for _, group := range ch.Events {
+			if _, ok := eventGroups[group]; !ok {
+				logger.Warn("Unknown event group in channel config", zap.String("channel", ch.Name), zap.String("group", group))
+			}
+		}
 	}
+	c.appToken.refreshed(time.Now().UTC().Add(time.Second * time.Duration(appToken.Data.ExpiresIn)))
 
 	// 2. Verify User Permissions (using DB or Config)
 	if len(monitoringChannels) == 0 {
@@ -77,9 +141,11 @@ func NewClient(cfg config.TwitchConfig, monitoringChannels []string, baseURL str
 		logger.Error("Could not resolve user ID", zap.String("login", primaryLogin))
 	}
 	var userID string
-	if len(usersResp.Data.Users) > 0 {
+	if err == nil && len(usersResp.Data.Users) > 0 {
 		userID = usersResp.Data.Users[0].ID
 	}
+	c.userID = userID
+	c.trackUserTokens(userID)
 
 	// 3. Maintain User Token Lifecycle (Refresh if needed)
 	if userID != "" {
@@ -91,17 +157,14 @@ func NewClient(cfg config.TwitchConfig, monitoringChannels []string, baseURL str
 		// Fallback: Try to fix missing ID using config token if available
 		if cfg.UserAccessToken != "" {
 			logger.Info("Validating config token as fallback...")
-			helixClient.SetUserAccessToken(cfg.UserAccessToken)
 			isValid, _, _ := helixClient.ValidateToken(cfg.UserAccessToken)
 			if isValid {
 				logger.Info("Config token is valid")
 			}
-			helixClient.SetUserAccessToken("")
 		}
 	}
 
-	// 4. FINAL STEP: Ensure Client uses App Token
-	helixClient.SetUserAccessToken("")
+	// The shared client only ever holds the app token; user token calls go through withUserToken
 	logger.Info("Twitch Client initialized (App Access Token active)")
 
 	return c, nil
@@ -164,7 +227,7 @@ func (c *Client) refreshToken(creds *database.TwitchCredentials) (*database.Twit
 
 // StartMonitoring sets up EventSub subscriptions for the configured channels.
 func (c *Client) StartMonitoring(channelLogins []string) error {
-	if c.selfBaseURL == "" {
+	if !c.usesWebSocketTransport() && c.selfBaseURL == "" {
 		return errors.New("baseURL is empty")
 	}
 
@@ -176,54 +239,90 @@ func (c *Client) StartMonitoring(channelLogins []string) error {
 	if len(usersResp.Data.Users) == 0 {
 		return nil
 	}
-
-	callbackURL := c.selfBaseURL + "/webhooks/twitch"
-
+	c.loadLiveState(usersResp.Data.Users)
 	for _, user := range usersResp.Data.Users {
-		c.logger.Info("Subscribing to events", zap.String("user", user.Login), zap.String("id", user.ID))
-		c.subscribeToEvent(user.ID, EventSubFollow, "2", callbackURL)
-		c.subscribeToRaidEvent(user.ID, callbackURL)
-		c.subscribeToEvent(user.ID, EventSubSubscribe, "1", callbackURL)
-		c.subscribeToEvent(user.ID, EventSubSubGift, "1", callbackURL)
-		c.subscribeToEvent(user.ID, EventSubSubMessage, "1", callbackURL)
-		c.subscribeToEvent(user.ID, EventSubCheer, "1", callbackURL)
+		c.trackUserTokens(user.ID)
+	}
+
+	// WebSocket transport: subscriptions are created once the session is welcomed
+	if c.usesWebSocketTransport() {
+		go c.runEventSubWebSocket(usersResp.Data.Users)
+		return nil
 	}
+
+	go c.runReconciler(usersResp.Data.Users, c.webhookTransport())
 	return nil
 }
 
+// webhookTransport returns the transport pointing Twitch at our webhook endpoint.
+func (c *Client) webhookTransport() helix.EventSubTransport {
+	return helix.EventSubTransport{
+		Method:   TransportWebhook,
+		Callback: c.selfBaseURL + "/webhooks/twitch",
+		Secret:   c.config.WebhookSecret,
+	}
+}
+
+// usesWebSocketTransport reports whether EventSub is delivered over WebSocket instead of webhooks.
+func (c *Client) usesWebSocketTransport() bool {
+	return c.config.EventSubTransport == TransportWebSocket
+}
+
+// subscribeChannel creates every EventSub subscription for one broadcaster on the given transport.
+// api is the shared app token client for webhooks, or a user token client for WebSocket sessions.
+func (c *Client) subscribeChannel(api *helix.Client, user helix.User, transport helix.EventSubTransport) {
+	c.logger.Info("Subscribing to events", zap.String("user", user.Login), zap.String("id", user.ID), zap.String("transport", transport.Method))
+	for _, spec := range c.desiredSubscriptions(user.Login) {
+		if spec.Type == EventSubRaid {
+			c.subscribeToRaidEvent(api, user.ID, transport)
+			continue
+		}
+		c.subscribeToEvent(api, user.ID, spec.Type, spec.Version, transport)
+	}
+}
+
 // subscribeToEvent creates a subscription if not already active in the DB.
-func (c *Client) subscribeToEvent(userID, eventType, version, callbackURL string) {
-	sub, err := c.db.GetSubscription(userID, eventType)
-	if err == nil && sub.Status == "enabled" {
-		return // Already active
+// WebSocket subscriptions are bound to a session, so they are always created and never persisted.
+func (c *Client) subscribeToEvent(api *helix.Client, userID, eventType, version string, transport helix.EventSubTransport) {
+	if transport.Method == TransportWebhook {
+		sub, err := c.db.GetSubscription(userID, eventType)
+		if err == nil && sub.Status == SubscriptionEnabled {
+			return // Already active
+		}
 	}
 
-	newSub, err := c.createSubscription(userID, eventType, version, callbackURL)
+	newSub, err := c.createSubscription(api, userID, eventType, version, transport)
 	if err != nil {
 		c.logger.Error("Subscription failed", zap.String("type", eventType), zap.Error(err))
 		return
 	}
 
-	if err := c.saveSubscriptionToDB(userID, eventType, newSub); err != nil {
-		c.logger.Info("Synced subscription to DB", zap.String("type", eventType))
+	if transport.Method == TransportWebhook {
+		if err := c.saveSubscriptionToDB(userID, eventType, newSub); err != nil {
+			c.logger.Warn("Failed to save subscription to DB", zap.String("type", eventType), zap.Error(err))
+		}
 	}
 }
 
 // subscribeToRaidEvent handles the specific requirements for raid subscriptions.
-func (c *Client) subscribeToRaidEvent(userID, callbackURL string) {
-	sub, err := c.db.GetSubscription(userID, EventSubRaid)
-	if err == nil && sub.Status == "enabled" {
-		return
+func (c *Client) subscribeToRaidEvent(api *helix.Client, userID string, transport helix.EventSubTransport) {
+	if transport.Method == TransportWebhook {
+		sub, err := c.db.GetSubscription(userID, EventSubRaid)
+		if err == nil && sub.Status == SubscriptionEnabled {
+			return
+		}
 	}
 
-	newSub, err := c.createRaidSubscription(userID, callbackURL)
+	newSub, err := c.createRaidSubscription(api, userID, transport)
 	if err != nil {
 		c.logger.Error("Raid subscription failed", zap.Error(err))
 		return
 	}
 
-	if err := c.saveSubscriptionToDB(userID, EventSubRaid, newSub); err != nil {
-		c.logger.Info("Synced raid subscription to DB")
+	if transport.Method == TransportWebhook {
+		if err := c.saveSubscriptionToDB(userID, EventSubRaid, newSub); err != nil {
+			c.logger.Warn("Failed to save raid subscription to DB", zap.Error(err))
+		}
 	}
 }
 
@@ -239,61 +338,53 @@ func (c *Client) saveSubscriptionToDB(userID, eventType string, sub *helix.Event
 }
 
 // createSubscription performs the API call for standard events with 409 auto-recovery.
-func (c *Client) createSubscription(userID, eventType, version, callbackURL string) (*helix.EventSubSubscription, error) {
+func (c *Client) createSubscription(api *helix.Client, userID, eventType, version string, transport helix.EventSubTransport) (*helix.EventSubSubscription, error) {
 	condition := helix.EventSubCondition{BroadcasterUserID: userID}
 	if eventType == EventSubFollow {
 		condition.ModeratorUserID = userID
 	}
 
-	resp, err := c.helix.CreateEventSubSubscription(&helix.EventSubSubscription{
+	resp, err := api.CreateEventSubSubscription(&helix.EventSubSubscription{
 		Type:      eventType,
 		Version:   version,
 		Condition: condition,
-		Transport: helix.EventSubTransport{
-			Method:   "webhook",
-			Callback: callbackURL,
-			Secret:   c.config.WebhookSecret,
-		},
+		Transport: transport,
 	})
 
 	// Handle 409 Conflict (Already Exists) by fetching the existing one
 	if resp != nil && resp.StatusCode == 409 {
-		return c.fetchExistingSubscription(userID, eventType)
+		return c.fetchExistingSubscription(api, userID, eventType)
 	}
 
 	return c.handleSubscriptionResponse(resp, err)
 }
 
 // createRaidSubscription performs the API call for raid events with 409 auto-recovery.
-func (c *Client) createRaidSubscription(userID, callbackURL string) (*helix.EventSubSubscription, error) {
-	resp, err := c.helix.CreateEventSubSubscription(&helix.EventSubSubscription{
+func (c *Client) createRaidSubscription(api *helix.Client, userID string, transport helix.EventSubTransport) (*helix.EventSubSubscription, error) {
+	resp, err := api.CreateEventSubSubscription(&helix.EventSubSubscription{
 		Type:    EventSubRaid,
 		Version: "1",
 		Condition: helix.EventSubCondition{
 			ToBroadcasterUserID: userID,
 		},
-		Transport: helix.EventSubTransport{
-			Method:   "webhook",
-			Callback: callbackURL,
-			Secret:   c.config.WebhookSecret,
-		},
+		Transport: transport,
 	})
 
 	// Handle 409 Conflict (Already Exists)
 	if resp != nil && resp.StatusCode == 409 {
-		return c.fetchExistingSubscription(userID, EventSubRaid)
+		return c.fetchExistingSubscription(api, userID, EventSubRaid)
 	}
 
 	return c.handleSubscriptionResponse(resp, err)
 }
 
 // fetchExistingSubscription retrieves all subs of a type and filters client-side to ensure we find it.
-func (c *Client) fetchExistingSubscription(userID, eventType string) (*helix.EventSubSubscription, error) {
+func (c *Client) fetchExistingSubscription(api *helix.Client, userID, eventType string) (*helix.EventSubSubscription, error) {
 	opts := &helix.EventSubSubscriptionsParams{
 		Type: eventType,
 	}
 
-	resp, err := c.helix.GetEventSubSubscriptions(opts)
+	resp, err := api.GetEventSubSubscriptions(opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch existing sub list: %w", err)
 	}
@@ -346,8 +437,24 @@ func (c *Client) HandleEventSubCallback(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Replay protection: Twitch recommends rejecting messages older than 10 minutes
+	if isStaleTimestamp(r.Header.Get("Twitch-Eventsub-Message-Timestamp"), time.Now().UTC()) {
+		c.logger.Warn("Rejected stale EventSub message", zap.String("id", r.Header.Get("Twitch-Eventsub-Message-Id")))
+		http.Error(w, "Stale Message", http.StatusForbidden)
+		return
+	}
+
+	messageID := r.Header.Get("Twitch-Eventsub-Message-Id")
 	messageType := r.Header.Get("Twitch-Eventsub-Message-Type")
 
+	// Twitch retries deliveries: acknowledge duplicates without processing them again.
+	// IDs are recorded only after a successful dispatch so a failed delivery can be retried.
+	if (messageType == "notification" || messageType == "revocation") && c.dedup.isDuplicate(messageID) {
+		c.logger.Info("Duplicate EventSub message ignored", zap.String("id", messageID), zap.String("type", messageType))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
 	switch messageType {
 	case "webhook_callback_verification":
 		var verification struct {
@@ -366,7 +473,8 @@ func (c *Client) HandleEventSubCallback(w http.ResponseWriter, r *http.Request)
 			Event        json.RawMessage            `json:"event"`
 		}
 		if err := json.Unmarshal(body, &notification); err == nil {
-			c.handleNotification(notification.Subscription.Type, notification.Event)
+			c.handleNotification(messageID, notification.Subscription.Type, notification.Event)
+			c.dedup.record(messageID)
 			w.WriteHeader(http.StatusOK)
 			w.Write([]byte("OK"))
 		} else {
@@ -378,8 +486,8 @@ func (c *Client) HandleEventSubCallback(w http.ResponseWriter, r *http.Request)
 			Subscription helix.EventSubSubscription `json:"subscription"`
 		}
 		if err := json.Unmarshal(body, &revocation); err == nil {
-			c.logger.Warn("Subscription revoked", zap.String("id", revocation.Subscription.ID))
-			c.db.DeleteSubscription(revocation.Subscription.ID)
+			c.handleRevocation(revocation.Subscription, c.webhookTransport())
+			c.dedup.record(messageID)
 		}
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
@@ -408,78 +516,96 @@ func (c *Client) verifyEventSubSignature(r *http.Request, body []byte) bool {
 	return hmac.Equal([]byte(sig), []byte(expected))
 }
 
-// handleNotification distributes events to the WebSocket hub.
-func (c *Client) handleNotification(eventType string, eventData json.RawMessage) {
-	var payload map[string]interface{}
+// handleNotification converts EventSub payloads into Event envelopes and sends them to the WebSocket hub.
+func (c *Client) handleNotification(messageID, eventType string, eventData json.RawMessage) {
+	var evt *events.Event
 	var err error
 
 	switch eventType {
 	case EventSubFollow:
 		var e helix.EventSubChannelFollowEvent
 		if err = json.Unmarshal(eventData, &e); err == nil {
-			payload = map[string]interface{}{
-				"type":      "twitch_follow",
-				"user_name": e.UserName,
-			}
+			evt = events.New(events.PlatformTwitch, events.TypeTwitchFollow, messageID,
+				&events.Actor{ID: e.UserID, Login: e.UserLogin, DisplayName: e.UserName}, nil)
 		}
 	case EventSubSubscribe:
 		var e helix.EventSubChannelSubscribeEvent
 		if err = json.Unmarshal(eventData, &e); err == nil {
-			payload = map[string]interface{}{
-				"type":      "twitch_subscribe",
-				"user_name": e.UserName,
-				"tier":      e.Tier,
-				"is_gift":   e.IsGift,
-			}
+			evt = events.New(events.PlatformTwitch, events.TypeTwitchSubscribe, messageID,
+				&events.Actor{ID: e.UserID, Login: e.UserLogin, DisplayName: e.UserName},
+				events.SubscribeData{Tier: e.Tier, IsGift: e.IsGift})
 		}
 	case EventSubSubMessage:
 		var e helix.EventSubChannelSubscriptionMessageEvent
 		if err = json.Unmarshal(eventData, &e); err == nil {
-			payload = map[string]interface{}{
-				"type":              "twitch_resubscribe",
-				"user_name":         e.UserName,
-				"tier":              e.Tier,
-				"message":           e.Message.Text,
-				"cumulative_months": e.CumulativeMonths,
-			}
+			evt = events.New(events.PlatformTwitch, events.TypeTwitchResubscribe, messageID,
+				&events.Actor{ID: e.UserID, Login: e.UserLogin, DisplayName: e.UserName},
+				events.ResubscribeData{Tier: e.Tier, Message: e.Message.Text, CumulativeMonths: e.CumulativeMonths})
 		}
 	case EventSubSubGift:
 		var e helix.EventSubChannelSubscriptionGiftEvent
 		if err = json.Unmarshal(eventData, &e); err == nil {
-			payload = map[string]interface{}{
-				"type":        "twitch_gift_sub",
-				"gifter_name": e.UserName,
-				"total_gifts": e.Total,
-				"tier":        e.Tier,
-			}
+			evt = events.New(events.PlatformTwitch, events.TypeTwitchGiftSub, messageID,
+				&events.Actor{ID: e.UserID, Login: e.UserLogin, DisplayName: e.UserName},
+				events.GiftSubData{Tier: e.Tier, Total: e.Total, IsAnonymous: e.IsAnonymous})
 		}
 	case EventSubCheer:
 		var e helix.EventSubChannelCheerEvent
 		if err = json.Unmarshal(eventData, &e); err == nil {
-			payload = map[string]interface{}{
-				"type":      "twitch_cheer",
-				"user_name": e.UserName,
-				"bits":      e.Bits,
-				"message":   e.Message,
-			}
+			evt = events.New(events.PlatformTwitch, events.TypeTwitchCheer, messageID,
+				&events.Actor{ID: e.UserID, Login: e.UserLogin, DisplayName: e.UserName},
+				events.CheerData{Bits: e.Bits, Message: e.Message, IsAnonymous: e.IsAnonymous})
 		}
 	case EventSubRaid:
 		var e helix.EventSubChannelRaidEvent
 		if err = json.Unmarshal(eventData, &e); err == nil {
-			payload = map[string]interface{}{
-				"type":        "twitch_raid",
-				"raider_name": e.FromBroadcasterUserName,
-				"viewers":     e.Viewers,
-			}
+			evt = events.New(events.PlatformTwitch, events.TypeTwitchRaid, messageID,
+				&events.Actor{ID: e.FromBroadcasterUserID, Login: e.FromBroadcasterUserLogin, DisplayName: e.FromBroadcasterUserName},
+				events.RaidData{Viewers: e.Viewers})
+		}
+	case EventSubRedemption:
+		var e helix.EventSubChannelPointsCustomRewardRedemptionEvent
+		if err = json.Unmarshal(eventData, &e); err == nil {
+			// Runs asynchronously: the Helix status update must not delay the webhook response
+			go c.handleRedemption(messageID, e)
+		}
+	case EventSubStreamOnline:
+		var e helix.EventSubStreamOnlineEvent
+		if err = json.Unmarshal(eventData, &e); err == nil {
+			evt = c.handleStreamOnline(messageID, e)
 		}
+	case EventSubStreamOffline:
+		var e helix.EventSubStreamOfflineEvent
+		if err = json.Unmarshal(eventData, &e); err == nil {
+			evt = c.handleStreamOffline(messageID, e)
+		}
+	case EventSubHypeTrainBegin, EventSubHypeTrainProgress, EventSubHypeTrainEnd,
+		EventSubPollBegin, EventSubPollProgress, EventSubPollEnd,
+		EventSubPredictionBegin, EventSubPredictionProgress, EventSubPredictionLock, EventSubPredictionEnd:
+		evt, err = progressEvent(messageID, eventType, eventData)
 	}
 
 	if err != nil {
 		c.logger.Error("Failed to parse event", zap.String("type", eventType), zap.Error(err))
 		return
 	}
-	if payload != nil {
-		data, _ := json.Marshal(payload)
-		c.hub.Broadcast <- data
+	if evt != nil {
+		c.hub.Publish(evt.FromChannel(notificationChannel(eventType, eventData)))
+	}
+}
+
+// notificationChannel returns the login of the broadcaster a notification belongs to.
+// For raids this is the channel being raided.
+func notificationChannel(eventType string, eventData json.RawMessage) string {
+	var owner struct {
+		BroadcasterUserLogin   string `json:"broadcaster_user_login"`
+		ToBroadcasterUserLogin string `json:"to_broadcaster_user_login"`
+	}
+	if err := json.Unmarshal(eventData, &owner); err != nil {
+		return ""
+	}
+	if eventType == EventSubRaid {
+		return strings.ToLower(owner.ToBroadcasterUserLogin)
 	}
+	return strings.ToLower(owner.BroadcasterUserLogin)
 }
@@ -0,0 +1,338 @@
+package twitch
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"VLX_Robot/internal/config"
+	"VLX_Robot/internal/database"
+	"VLX_Robot/internal/events"
+	"VLX_Robot/internal/websocket"
+
+	"github.com/nicklaw5/helix/v2"
+	"go.uber.org/zap"
+)
+
+func TestMaintainUserToken(t *testing.T) {
+	tests := []struct {
+		name        string
+		creds       *database.TwitchCredentials
+		configToken string
+		wantErr     bool
+	}{
+		{"NoCreds_WithConfigToken", nil, "config_token", false},
+		{"NoCreds_NoConfigToken", nil, "", true},
+		{"ValidCreds", &database.TwitchCredentials{UserID: "123", AccessToken: "a", RefreshToken: "r", ExpiresAt: time.Now().Add(time.Hour)}, "", false},
+		{"ExpiredCreds_NoRefreshToken", &database.TwitchCredentials{UserID: "123", AccessToken: "a", ExpiresAt: time.Now().Add(-time.Hour)}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := database.NewMemoryStore()
+			if tt.creds != nil {
+				store.UpsertTwitchCredentials(tt.creds)
+			}
+			c := &Client{db: store, logger: zap.NewNop()}
+
+			err := c.maintainUserToken("123", config.TwitchConfig{UserAccessToken: tt.configToken})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("maintainUserToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSubscribeToEventSkipsEnabled(t *testing.T) {
+	store := database.NewMemoryStore()
+	store.CreateSubscription(&database.TwitchSubscription{
+		ID:        "sub-1",
+		UserID:    "123",
+		EventType: EventSubFollow,
+		Status:    "enabled",
+		CreatedAt: time.Now(),
+	})
+
+	// helix is nil: any API call would panic, so reaching the end proves the DB short-circuit worked.
+	c := &Client{db: store, logger: zap.NewNop()}
+	c.subscribeToEvent(c.helix, "123", EventSubFollow, "2", helix.EventSubTransport{
+		Method:   TransportWebhook,
+		Callback: "https://example.com/webhooks/twitch",
+	})
+
+	sub, err := store.GetSubscription("123", EventSubFollow)
+	if err != nil || sub.ID != "sub-1" {
+		t.Errorf("Expected existing subscription to be kept, got %+v (err: %v)", sub, err)
+	}
+}
+
+func TestHandleNotificationEnvelope(t *testing.T) {
+	logger := zap.NewNop()
+	hub := websocket.NewHub(logger)
+	c := &Client{hub: hub, logger: logger}
+
+	raw := json.RawMessage(`{"from_broadcaster_user_id":"42","from_broadcaster_user_login":"raider","from_broadcaster_user_name":"Raider","to_broadcaster_user_login":"target","viewers":17}`)
+	go c.handleNotification("msg-1", EventSubRaid, raw)
+
+	select {
+	case msg := <-hub.Broadcast:
+		var evt struct {
+			Version  int             `json:"version"`
+			Platform string          `json:"platform"`
+			Channel  string          `json:"channel"`
+			Type     string          `json:"type"`
+			ID       string          `json:"id"`
+			Actor    events.Actor    `json:"actor"`
+			Data     events.RaidData `json:"data"`
+		}
+		if err := json.Unmarshal(mustMarshal(t, msg), &evt); err != nil {
+			t.Fatalf("Failed to unmarshal JSON: %v", err)
+		}
+		if msg.Topic() != events.TopicAlerts {
+			t.Errorf("Expected topic alerts, got %s", msg.Topic())
+		}
+		if evt.Version != events.SchemaVersion || evt.Platform != events.PlatformTwitch || evt.Type != events.TypeTwitchRaid {
+			t.Errorf("Unexpected envelope header: %+v", evt)
+		}
+		if evt.Channel != "target" {
+			t.Errorf("Expected the raided channel as source, got %q", evt.Channel)
+		}
+		if evt.ID != "msg-1" || evt.Actor.DisplayName != "Raider" || evt.Data.Viewers != 17 {
+			t.Errorf("Unexpected envelope content: %+v", evt)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for broadcast")
+	}
+}
+
+func mustMarshal(t *testing.T, evt *events.Event) []byte {
+	t.Helper()
+	data, err := evt.Marshal()
+	if err != nil {
+		t.Fatalf("Failed to marshal event: %v", err)
+	}
+	return data
+}
+
+func TestHandleEventSubCallbackReplayProtection(t *testing.T) {
+	logger := zap.NewNop()
+	hub := websocket.NewHub(logger)
+	c := &Client{
+		config: config.TwitchConfig{WebhookSecret: "secret"},
+		hub:    hub,
+		dedup:  newMessageDeduplicator(maxMessageAge, maxSeenMessages, nil, logger),
+		logger: logger,
+	}
+
+	received := make(chan *events.Event, 10)
+	go func() {
+		for evt := range hub.Broadcast {
+			received <- evt
+		}
+	}()
+
+	body := `{"subscription":{"type":"channel.follow"},"event":{"user_name":"Viewer"}}`
+	send := func(id string, ts time.Time) int {
+		req := signedRequest("secret", id, ts.Format(time.RFC3339Nano), body)
+		rec := httptest.NewRecorder()
+		c.HandleEventSubCallback(rec, req)
+		return rec.Code
+	}
+
+	if code := send("msg-1", time.Now()); code != http.StatusOK {
+		t.Fatalf("Expected 200 for first delivery, got %d", code)
+	}
+	if code := send("msg-1", time.Now()); code != http.StatusOK {
+		t.Fatalf("Expected 200 for duplicate delivery, got %d", code)
+	}
+	if code := send("msg-2", time.Now().Add(-11*time.Minute)); code != http.StatusForbidden {
+		t.Fatalf("Expected 403 for stale message, got %d", code)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if len(received) != 1 {
+		t.Errorf("Expected exactly 1 broadcast, got %d", len(received))
+	}
+}
+
+func TestHandleEventSubCallbackRetryAfterFailure(t *testing.T) {
+	logger := zap.NewNop()
+	hub := websocket.NewHub(logger)
+	c := &Client{
+		config: config.TwitchConfig{WebhookSecret: "secret"},
+		hub:    hub,
+		dedup:  newMessageDeduplicator(maxMessageAge, maxSeenMessages, nil, logger),
+		logger: logger,
+	}
+
+	received := make(chan *events.Event, 10)
+	go func() {
+		for evt := range hub.Broadcast {
+			received <- evt
+		}
+	}()
+
+	send := func(body string) int {
+		req := signedRequest("secret", "msg-1", time.Now().Format(time.RFC3339Nano), body)
+		rec := httptest.NewRecorder()
+		c.HandleEventSubCallback(rec, req)
+		return rec.Code
+	}
+
+	// First delivery is truncated and fails to decode
+	if code := send(`{"subscription":{"type":"channel.follow"`); code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for malformed delivery, got %d", code)
+	}
+	// Twitch retries with the same message ID
+	if code := send(`{"subscription":{"type":"channel.follow"},"event":{"user_name":"Viewer"}}`); code != http.StatusOK {
+		t.Fatalf("Expected 200 for retry, got %d", code)
+	}
+
+	select {
+	case evt := <-received:
+		if evt.Type != events.TypeTwitchFollow {
+			t.Errorf("Expected follow event, got %s", evt.Type)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Retry after a failed delivery was not processed")
+	}
+}
+
+// signedRequest builds an EventSub notification request with a valid HMAC signature.
+func signedRequest(secret, id, ts, body string) *http.Request {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(id + ts + body))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/twitch", strings.NewReader(body))
+	req.Header.Set("Twitch-Eventsub-Message-Id", id)
+	req.Header.Set("Twitch-Eventsub-Message-Timestamp", ts)
+	req.Header.Set("Twitch-Eventsub-Message-Type", "notification")
+	req.Header.Set("Twitch-Eventsub-Message-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func TestStreamLifecycle(t *testing.T) {
+	logger := zap.NewNop()
+	hub := websocket.NewHub(logger)
+	chat := &fakeChatSender{}
+	c := &Client{
+		config: config.TwitchConfig{GoLiveMessage: "${channel} is live!"},
+		hub:    hub,
+		chat:   chat,
+		live:   make(map[string]bool),
+		logger: logger,
+	}
+
+	changes := make(chan bool, 4)
+	c.OnLiveChange(func(channel string, live bool) {
+		if channel == "streamer" {
+			changes <- live
+		}
+	})
+
+	online := json.RawMessage(`{"broadcaster_user_id":"1","broadcaster_user_login":"Streamer","broadcaster_user_name":"Streamer","type":"live"}`)
+	offline := json.RawMessage(`{"broadcaster_user_id":"1","broadcaster_user_login":"Streamer","broadcaster_user_name":"Streamer"}`)
+
+	received := make(chan *events.Event, 4)
+	go func() {
+		for evt := range hub.Broadcast {
+			received <- evt
+		}
+	}()
+
+	c.handleNotification("msg-1", EventSubStreamOnline, online)
+	c.handleNotification("msg-2", EventSubStreamOnline, online) // Repeated state is ignored
+
+	select {
+	case evt := <-received:
+		if evt.Type != events.TypeTwitchStreamOnline || evt.Topic() != events.TopicStatus {
+			t.Errorf("Unexpected event %s on %s", evt.Type, evt.Topic())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for online event")
+	}
+	if !c.IsLive("streamer") {
+		t.Error("Expected channel to be live")
+	}
+	if live := <-changes; !live {
+		t.Error("Expected online notification")
+	}
+
+	c.handleNotification("msg-3", EventSubStreamOffline, offline)
+	select {
+	case evt := <-received:
+		if evt.Type != events.TypeTwitchStreamOffline {
+			t.Errorf("Expected offline event, got %s", evt.Type)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for offline event")
+	}
+	if c.IsLive("streamer") {
+		t.Error("Expected channel to be offline")
+	}
+	if live := <-changes; live {
+		t.Error("Expected offline notification")
+	}
+	select {
+	case <-changes:
+		t.Error("Duplicate online notification should not reach listeners")
+	default:
+	}
+
+	// Announcement is sent asynchronously
+	time.Sleep(50 * time.Millisecond)
+	if channel, message := chat.last(); channel != "Streamer" || message != "Streamer is live!" {
+		t.Errorf("Unexpected go-live announcement %q on %q", message, channel)
+	}
+}
+
+func TestRequiredScopes(t *testing.T) {
+	c := &Client{}
+	for _, scope := range c.RequiredScopes() {
+		if scope == "channel:manage:redemptions" {
+			t.Error("Redemption scope requested without configured rewards")
+		}
+	}
+
+	c.config.Rewards = []config.RewardConfig{{Title: "Hydrate", Action: RewardActionAlert}}
+	found := false
+	for _, scope := range c.RequiredScopes() {
+		found = found || scope == "channel:manage:redemptions"
+	}
+	if !found {
+		t.Error("Expected redemption scope when rewards are configured")
+	}
+}
+
+func TestLiveListenersInOrder(t *testing.T) {
+	c := &Client{live: make(map[string]bool), logger: zap.NewNop()}
+
+	changes := make(chan bool, 8)
+	c.OnLiveChange(func(channel string, live bool) {
+		if live {
+			time.Sleep(10 * time.Millisecond) // A slow listener must not let later transitions overtake
+		}
+		changes <- live
+	})
+
+	want := []bool{true, false, true, false}
+	for _, live := range want {
+		c.setLive("streamer", live)
+	}
+	for i, w := range want {
+		select {
+		case got := <-changes:
+			if got != w {
+				t.Fatalf("Transition %d: expected live=%v, got %v", i, w, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timeout waiting for transition")
+		}
+	}
+}
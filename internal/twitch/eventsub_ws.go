@@ -0,0 +1,225 @@
+package twitch
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	gorillaws "github.com/gorilla/websocket"
+	"github.com/nicklaw5/helix/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultEventSubWebSocketURL is Twitch's production EventSub WebSocket endpoint.
+	DefaultEventSubWebSocketURL = "wss://eventsub.wss.twitch.tv/ws"
+
+	// keepaliveGrace is added to the server keepalive timeout before the session is considered dead.
+	keepaliveGrace = 5 * time.Second
+	// welcomeTimeout bounds how long we wait for session_welcome after dialing.
+	welcomeTimeout = 15 * time.Second
+	// maxWSBackoff caps the delay between reconnection attempts.
+	maxWSBackoff = 2 * time.Minute
+)
+
+// eventSubWSMessage is the frame format of the EventSub WebSocket transport.
+type eventSubWSMessage struct {
+	Metadata struct {
+		MessageID        string `json:"message_id"`
+		MessageType      string `json:"message_type"`
+		MessageTimestamp string `json:"message_timestamp"`
+		SubscriptionType string `json:"subscription_type"`
+	} `json:"metadata"`
+	Payload struct {
+		Session *struct {
+			ID                      string `json:"id"`
+			Status                  string `json:"status"`
+			KeepaliveTimeoutSeconds int    `json:"keepalive_timeout_seconds"`
+			ReconnectURL            string `json:"reconnect_url"`
+		} `json:"session"`
+		Subscription helix.EventSubSubscription `json:"subscription"`
+		Event        json.RawMessage            `json:"event"`
+	} `json:"payload"`
+}
+
+// eventSubSession is a live connection to the EventSub WebSocket endpoint.
+type eventSubSession struct {
+	conn      *gorillaws.Conn
+	id        string
+	keepalive time.Duration
+}
+
+// runEventSubWebSocket keeps an EventSub WebSocket session alive and (re)creates subscriptions for every new session.
+func (c *Client) runEventSubWebSocket(users []helix.User) {
+	endpoint := c.config.EventSubWebSocketURL
+	if endpoint == "" {
+		endpoint = DefaultEventSubWebSocketURL
+	}
+
+	backoff := time.Second
+	for {
+		session, err := c.connectEventSub(endpoint)
+		if err != nil {
+			c.logger.Error("EventSub WebSocket connection failed", zap.Duration("retry_in", backoff), zap.Error(err))
+			time.Sleep(backoff)
+			backoff = min(backoff*2, maxWSBackoff)
+			continue
+		}
+		backoff = time.Second
+
+		// A new session has no subscriptions: bind them to this session ID
+		c.setWebSocketSession(session.id)
+		c.subscribeSession(users, session.id)
+
+		err = c.serveEventSub(session)
+		c.logger.Warn("EventSub WebSocket session ended, starting a new one", zap.Error(err))
+	}
+}
+
+// setWebSocketSession records the session ID new WebSocket subscriptions must be bound to.
+func (c *Client) setWebSocketSession(id string) {
+	c.wsMu.Lock()
+	c.wsSessionID = id
+	c.wsMu.Unlock()
+}
+
+// webSocketTransport returns the transport bound to the current WebSocket session.
+func (c *Client) webSocketTransport() helix.EventSubTransport {
+	c.wsMu.Lock()
+	defer c.wsMu.Unlock()
+	return helix.EventSubTransport{Method: TransportWebSocket, SessionID: c.wsSessionID}
+}
+
+// subscribeSession creates the channel subscriptions on a WebSocket session using each broadcaster's user token.
+func (c *Client) subscribeSession(users []helix.User, sessionID string) {
+	transport := helix.EventSubTransport{Method: TransportWebSocket, SessionID: sessionID}
+	for _, user := range users {
+		err := c.withUserToken(user.ID, func(api *helix.Client) error {
+			c.subscribeChannel(api, user, transport)
+			return nil
+		})
+		if err != nil {
+			c.logger.Error("EventSub WebSocket subscriptions failed", zap.String("user", user.Login), zap.Error(err))
+		}
+	}
+}
+
+// withUserToken runs fn with a Helix client authorized by the broadcaster's user access token.
+// The token is never set on the shared client: Helix prefers a user token whenever one is set,
+// so concurrent app token calls would pick it up (or lose it to another call's reset).
+func (c *Client) withUserToken(userID string, fn func(api *helix.Client) error) error {
+	token := c.currentUserToken(userID)
+	if token == "" {
+		return errors.New("no user access token available")
+	}
+
+	api, err := helix.NewClient(&helix.Options{
+		ClientID:        c.config.ClientID,
+		UserAccessToken: token,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create helix client: %w", err)
+	}
+	return fn(api)
+}
+
+// currentUserToken returns the stored user access token of a broadcaster.
+// The primary channel falls back to the config token.
+func (c *Client) currentUserToken(userID string) string {
+	if userID != "" {
+		creds, err := c.db.GetTwitchCredentials(userID)
+		if err == nil && creds.AccessToken != "" {
+			return creds.AccessToken
+		}
+		if err != nil && err != sql.ErrNoRows {
+			c.logger.Warn("Could not load user token from DB", zap.Error(err))
+		}
+	}
+	if userID == c.userID {
+		return c.config.UserAccessToken
+	}
+	return ""
+}
+
+// connectEventSub dials the endpoint and waits for the session_welcome message.
+func (c *Client) connectEventSub(endpoint string) (*eventSubSession, error) {
+	conn, _, err := gorillaws.DefaultDialer.Dial(endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("dial failed: %w", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(welcomeTimeout))
+	var msg eventSubWSMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read welcome: %w", err)
+	}
+	if msg.Metadata.MessageType != "session_welcome" || msg.Payload.Session == nil {
+		conn.Close()
+		return nil, fmt.Errorf("expected session_welcome, got %q", msg.Metadata.MessageType)
+	}
+
+	session := &eventSubSession{
+		conn:      conn,
+		id:        msg.Payload.Session.ID,
+		keepalive: time.Duration(msg.Payload.Session.KeepaliveTimeoutSeconds) * time.Second,
+	}
+	c.logger.Info("EventSub WebSocket session established", zap.String("session_id", session.id), zap.Duration("keepalive", session.keepalive))
+	return session, nil
+}
+
+// serveEventSub reads messages until the session dies. session_reconnect is handled in place:
+// subscriptions carry over to the new connection, and later resubscriptions use the new session ID.
+func (c *Client) serveEventSub(session *eventSubSession) error {
+	defer func() { session.conn.Close() }()
+
+	for {
+		if session.keepalive > 0 {
+			session.conn.SetReadDeadline(time.Now().Add(session.keepalive + keepaliveGrace))
+		} else {
+			session.conn.SetReadDeadline(time.Time{})
+		}
+
+		var msg eventSubWSMessage
+		if err := session.conn.ReadJSON(&msg); err != nil {
+			return fmt.Errorf("read failed: %w", err)
+		}
+
+		switch msg.Metadata.MessageType {
+		case "session_keepalive":
+			// Read deadline already refreshed
+
+		case "notification":
+			if c.dedup != nil && c.dedup.isDuplicate(msg.Metadata.MessageID) {
+				c.logger.Info("Duplicate EventSub message ignored", zap.String("id", msg.Metadata.MessageID))
+				continue
+			}
+			c.handleNotification(msg.Metadata.MessageID, msg.Payload.Subscription.Type, msg.Payload.Event)
+			if c.dedup != nil {
+				c.dedup.record(msg.Metadata.MessageID)
+			}
+
+		case "revocation":
+			c.handleRevocation(msg.Payload.Subscription, c.webSocketTransport())
+
+		case "session_reconnect":
+			if msg.Payload.Session == nil || msg.Payload.Session.ReconnectURL == "" {
+				return errors.New("session_reconnect without reconnect_url")
+			}
+			c.logger.Info("EventSub WebSocket reconnect requested", zap.String("url", msg.Payload.Session.ReconnectURL))
+
+			next, err := c.connectEventSub(msg.Payload.Session.ReconnectURL)
+			if err != nil {
+				return fmt.Errorf("reconnect failed: %w", err)
+			}
+			session.conn.Close()
+			*session = *next
+			c.setWebSocketSession(session.id)
+
+		default:
+			c.logger.Debug("Unhandled EventSub WebSocket message", zap.String("type", msg.Metadata.MessageType))
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package twitch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"VLX_Robot/internal/events"
+	"VLX_Robot/internal/websocket"
+
+	gorillaws "github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// mockEventSubServer replays a scripted list of frames to every connecting client, then closes.
+func mockEventSubServer(t *testing.T, frames []string) *httptest.Server {
+	upgrader := gorillaws.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Upgrade failed: %v", err)
+			return
+		}
+		defer conn.Close()
+		for _, frame := range frames {
+			if err := conn.WriteMessage(gorillaws.TextMessage, []byte(frame)); err != nil {
+				return
+			}
+		}
+		// Give the client time to read before closing
+		time.Sleep(100 * time.Millisecond)
+	}))
+}
+
+func wsURL(server *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func TestEventSubWebSocketSession(t *testing.T) {
+	logger := zap.NewNop()
+	hub := websocket.NewHub(logger)
+	c := &Client{hub: hub, dedup: newMessageDeduplicator(maxMessageAge, maxSeenMessages, nil, logger), logger: logger}
+
+	received := make(chan *events.Event, 10)
+	go func() {
+		for evt := range hub.Broadcast {
+			received <- evt
+		}
+	}()
+
+	// Second server: the reconnect target
+	second := mockEventSubServer(t, []string{
+		`{"metadata":{"message_type":"session_welcome"},"payload":{"session":{"id":"s2","keepalive_timeout_seconds":10}}}`,
+		`{"metadata":{"message_id":"m2","message_type":"notification","subscription_type":"channel.raid"},"payload":{"subscription":{"type":"channel.raid"},"event":{"from_broadcaster_user_name":"Raider","viewers":5}}}`,
+	})
+	defer second.Close()
+
+	first := mockEventSubServer(t, []string{
+		`{"metadata":{"message_type":"session_welcome"},"payload":{"session":{"id":"s1","keepalive_timeout_seconds":10}}}`,
+		`{"metadata":{"message_type":"session_keepalive"},"payload":{}}`,
+		`{"metadata":{"message_id":"m1","message_type":"notification","subscription_type":"channel.follow"},"payload":{"subscription":{"type":"channel.follow"},"event":{"user_name":"Viewer"}}}`,
+		`{"metadata":{"message_id":"m1","message_type":"notification","subscription_type":"channel.follow"},"payload":{"subscription":{"type":"channel.follow"},"event":{"user_name":"Viewer"}}}`,
+		`{"metadata":{"message_type":"session_reconnect"},"payload":{"session":{"id":"s1","status":"reconnecting","reconnect_url":"` + wsURL(second) + `"}}}`,
+	})
+	defer first.Close()
+
+	session, err := c.connectEventSub(wsURL(first))
+	if err != nil {
+		t.Fatalf("connectEventSub failed: %v", err)
+	}
+	if session.id != "s1" || session.keepalive != 10*time.Second {
+		t.Errorf("Unexpected session: id=%s keepalive=%s", session.id, session.keepalive)
+	}
+
+	// Runs until the second server closes the connection
+	if err := c.serveEventSub(session); err == nil {
+		t.Error("Expected serveEventSub to end with an error after the server closed")
+	}
+
+	// Resubscriptions after the reconnect must target the new session
+	if id := c.webSocketTransport().SessionID; id != "s2" {
+		t.Errorf("Expected transport on session s2 after reconnect, got %q", id)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	var types []string
+	for len(received) > 0 {
+		types = append(types, (<-received).Type)
+	}
+	if strings.Join(types, ",") != events.TypeTwitchFollow+","+events.TypeTwitchRaid {
+		t.Errorf("Expected follow then raid (duplicate dropped), got %v", types)
+	}
+}
@@ -0,0 +1,96 @@
+package twitch
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/nicklaw5/helix/v2"
+)
+
+// followTTL bounds how often the follow age of the same viewer is looked up.
+const followTTL = 10 * time.Minute
+
+// maxFollowCache is the number of cached lookups above which expired entries are dropped.
+const maxFollowCache = 10000
+
+// followLookupTimeout bounds how long a chat message waits for a follow lookup.
+const followLookupTimeout = time.Second
+
+type cachedFollow struct {
+	followedAt time.Time
+	following  bool
+	fetchedAt  time.Time
+}
+
+// FollowedAt returns when a user followed a broadcaster, or false if they do not follow.
+// It needs the broadcaster's token with moderator:read:followers; results are cached briefly,
+// and concurrent lookups of the same user share one Helix request.
+func (c *Client) FollowedAt(broadcasterID, userID string) (time.Time, bool, error) {
+	key := broadcasterID + "/" + userID
+
+	c.followMu.Lock()
+	cached, ok := c.followCache[key]
+	if ok && time.Since(cached.fetchedAt) < followTTL {
+		c.followMu.Unlock()
+		return cached.followedAt, cached.following, nil
+	}
+	if wait, inFlight := c.followWait[key]; inFlight {
+		c.followMu.Unlock()
+		<-wait
+		c.followMu.Lock()
+		cached, ok = c.followCache[key]
+		c.followMu.Unlock()
+		if !ok {
+			return time.Time{}, false, fmt.Errorf("get channel followers: concurrent lookup failed")
+		}
+		return cached.followedAt, cached.following, nil
+	}
+	if c.followWait == nil {
+		c.followWait = make(map[string]chan struct{})
+	}
+	done := make(chan struct{})
+	c.followWait[key] = done
+	c.followMu.Unlock()
+	defer func() {
+		c.followMu.Lock()
+		delete(c.followWait, key)
+		c.followMu.Unlock()
+		close(done)
+	}()
+
+	var entry cachedFollow
+	err := c.withUserToken(broadcasterID, func(api *helix.Client) error {
+		resp, err := api.GetChannelFollows(&helix.GetChannelFollowersParams{BroadcasterID: broadcasterID, UserID: userID})
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("api status %d: %s", resp.StatusCode, resp.ErrorMessage)
+		}
+		if len(resp.Data.Channels) > 0 {
+			entry.followedAt = resp.Data.Channels[0].FollowedAt
+			entry.following = true
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("get channel followers: %w", err)
+	}
+
+	entry.fetchedAt = time.Now()
+	c.followMu.Lock()
+	if c.followCache == nil {
+		c.followCache = make(map[string]cachedFollow)
+	}
+	if len(c.followCache) > maxFollowCache {
+		for k, v := range c.followCache {
+			if entry.fetchedAt.Sub(v.fetchedAt) >= followTTL {
+				delete(c.followCache, k)
+			}
+		}
+	}
+	c.followCache[key] = entry
+	c.followMu.Unlock()
+	return entry.followedAt, entry.following, nil
+}
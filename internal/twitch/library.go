@@ -0,0 +1,152 @@
+package twitch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"VLX_Robot/internal/commands"
+
+	"go.uber.org/zap"
+)
+
+// DefaultReloadInterval is how often the command folders are polled for changes.
+const DefaultReloadInterval = 10 * time.Second
+
+// LibraryWatcher polls the static/chat command folders and swaps the registry's media commands when files change.
+type LibraryWatcher struct {
+	dir         string
+	roles       *commands.Roles // Decides which subfolders are scanned
+	interval    time.Duration
+	registry    *commands.Registry
+	announce    func(message string) // Optional chat announcement of added/removed commands
+	logger      *zap.Logger
+	fingerprint string // Folder listing at the last scan
+}
+
+// NewLibraryWatcher creates a watcher for dir. The registry should already hold the initial scan.
+func NewLibraryWatcher(dir string, roles *commands.Roles, interval time.Duration, registry *commands.Registry, logger *zap.Logger) *LibraryWatcher {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+	w := &LibraryWatcher{
+		dir:      dir,
+		roles:    roles,
+		interval: interval,
+		registry: registry,
+		logger:   logger,
+	}
+	w.fingerprint = w.listing()
+	return w
+}
+
+// SetAnnouncer posts a chat message whenever commands are added or removed.
+func (w *LibraryWatcher) SetAnnouncer(fn func(message string)) {
+	w.announce = fn
+}
+
+// Start polls the folders in the background.
+func (w *LibraryWatcher) Start() {
+	w.logger.Info("Watching command library", zap.String("dir", w.dir), zap.Duration("interval", w.interval))
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			w.check()
+		}
+	}()
+}
+
+// check rescans the library if the folder listing changed and applies the differences.
+func (w *LibraryWatcher) check() {
+	listing := w.listing()
+	if listing == w.fingerprint {
+		return
+	}
+	w.fingerprint = listing
+
+	scanned, err := ScanAudioCommands(w.dir, w.roles, w.logger)
+	if err != nil {
+		w.logger.Warn("Command library rescan failed", zap.Error(err))
+		return
+	}
+
+	added, removed, changed := diffCommands(w.registry.MediaCommands(), scanned)
+	if len(added)+len(removed)+len(changed) == 0 {
+		return
+	}
+	w.registry.SetMedia(scanned)
+
+	w.logger.Info("Command library reloaded",
+		zap.Strings("added", added),
+		zap.Strings("removed", removed),
+		zap.Strings("changed", changed),
+		zap.Int("total", len(scanned)))
+
+	if w.announce != nil && len(added)+len(removed) > 0 {
+		w.announce(announcement(added, removed))
+	}
+}
+
+// listing returns the sorted file names, sizes and modification times of every command folder,
+// used to detect changes cheaply (metadata edits keep the file name).
+func (w *LibraryWatcher) listing() string {
+	var names []string
+	for folder := range w.roles.Folders() {
+		files, err := os.ReadDir(filepath.Join(w.dir, folder))
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			entry := folder + "/" + f.Name()
+			if info, err := f.Info(); err == nil {
+				entry += fmt.Sprintf(" %d %d", info.Size(), info.ModTime().UnixNano())
+			}
+			names = append(names, entry)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, "\n")
+}
+
+// diffCommands compares two command sets. Changed commands kept their name but their file, folder or metadata changed.
+func diffCommands(old, current map[string]commands.MediaCommand) (added, removed, changed []string) {
+	for name, cmd := range current {
+		prev, ok := old[name]
+		switch {
+		case !ok:
+			added = append(added, name)
+		case !reflect.DeepEqual(prev, cmd):
+			changed = append(changed, name)
+		}
+	}
+	for name := range old {
+		if _, ok := current[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return added, removed, changed
+}
+
+// announcement formats the chat message for added and removed commands.
+func announcement(added, removed []string) string {
+	prefix := func(names []string) string {
+		return "!" + strings.Join(names, ", !")
+	}
+
+	var parts []string
+	if len(added) > 0 {
+		parts = append(parts, "New commands: "+prefix(added))
+	}
+	if len(removed) > 0 {
+		parts = append(parts, "Removed: "+prefix(removed))
+	}
+	return strings.Join(parts, " / ")
+}
@@ -0,0 +1,63 @@
+package twitch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"VLX_Robot/internal/commands"
+
+	"go.uber.org/zap"
+)
+
+func TestLibraryWatcherReload(t *testing.T) {
+	dir := t.TempDir()
+	for _, folder := range []string{"everyone", "vips"} {
+		if err := os.Mkdir(filepath.Join(dir, folder), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write := func(path string) {
+		if err := os.WriteFile(filepath.Join(dir, path), []byte("dummy"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("everyone/hello.mp3")
+	write("everyone/bye.mp3")
+
+	logger := zap.NewNop()
+	initial, _ := ScanAudioCommands(dir, commands.DefaultRoles(), logger)
+	registry := commands.NewRegistry(initial, nil)
+	w := NewLibraryWatcher(dir, commands.DefaultRoles(), 0, registry, logger)
+
+	var announced []string
+	w.SetAnnouncer(func(message string) { announced = append(announced, message) })
+
+	// Nothing changed on disk
+	w.check()
+	if len(announced) != 0 {
+		t.Fatalf("Unexpected announcement without changes: %v", announced)
+	}
+
+	write("everyone/new.wav")
+	if err := os.Remove(filepath.Join(dir, "everyone", "bye.mp3")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(dir, "everyone", "hello.mp3"), filepath.Join(dir, "vips", "hello.mp3")); err != nil {
+		t.Fatal(err)
+	}
+	w.check()
+
+	if _, ok := registry.Media("new"); !ok {
+		t.Error("Expected new command after reload")
+	}
+	if _, ok := registry.Media("bye"); ok {
+		t.Error("Expected removed command to be gone")
+	}
+	if cmd, _ := registry.Media("hello"); cmd.Permission != PermissionVIP {
+		t.Errorf("Expected moved command to become VIP-only, got %+v", cmd)
+	}
+	if len(announced) != 1 || announced[0] != "New commands: !new / Removed: !bye" {
+		t.Errorf("Unexpected announcements: %v", announced)
+	}
+}
@@ -0,0 +1,132 @@
+package twitch
+
+import (
+	"net/http"
+	"strings"
+
+	"VLX_Robot/internal/events"
+
+	"github.com/nicklaw5/helix/v2"
+	"go.uber.org/zap"
+)
+
+// Stream lifecycle EventSub types
+const (
+	EventSubStreamOnline  = "stream.online"
+	EventSubStreamOffline = "stream.offline"
+)
+
+// liveQueueSize is how many transitions may wait for the listeners before setLive blocks.
+const liveQueueSize = 64
+
+// LiveListener is notified when a monitored channel goes live or offline.
+type LiveListener func(channel string, live bool)
+
+// liveChange is a transition waiting to be delivered to the listeners.
+type liveChange struct {
+	channel string
+	live    bool
+}
+
+// OnLiveChange registers a listener for stream online/offline transitions.
+// Listeners run on a single goroutine that delivers transitions in order; they must not block for long.
+func (c *Client) OnLiveChange(fn LiveListener) {
+	c.liveMu.Lock()
+	defer c.liveMu.Unlock()
+	c.liveListeners = append(c.liveListeners, fn)
+	if c.liveChanges == nil {
+		c.liveChanges = make(chan liveChange, liveQueueSize)
+		go c.notifyLive(c.liveChanges)
+	}
+}
+
+// notifyLive delivers queued transitions to the listeners one at a time.
+func (c *Client) notifyLive(changes <-chan liveChange) {
+	for change := range changes {
+		c.liveMu.RLock()
+		listeners := append([]LiveListener(nil), c.liveListeners...)
+		c.liveMu.RUnlock()
+
+		for _, fn := range listeners {
+			fn(change.channel, change.live)
+		}
+	}
+}
+
+// IsLive reports whether the channel (login) is currently live.
+func (c *Client) IsLive(channel string) bool {
+	c.liveMu.RLock()
+	defer c.liveMu.RUnlock()
+	return c.live[strings.ToLower(channel)]
+}
+
+// loadLiveState seeds the live state from Helix so it is correct when the bot starts mid-stream.
+// Listeners are not notified: modules start in their default state.
+func (c *Client) loadLiveState(users []helix.User) {
+	ids := make([]string, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+
+	resp, err := c.helix.GetStreams(&helix.StreamsParams{UserIDs: ids})
+	if err != nil || resp.StatusCode != http.StatusOK {
+		c.logger.Warn("Could not load initial live state", zap.Error(err))
+		return
+	}
+
+	c.liveMu.Lock()
+	defer c.liveMu.Unlock()
+	for _, s := range resp.Data.Streams {
+		if s.Type == "live" {
+			c.live[strings.ToLower(s.UserLogin)] = true
+			c.logger.Info("Channel is live", zap.String("channel", s.UserLogin), zap.Time("started_at", s.StartedAt))
+		}
+	}
+}
+
+// setLive records a transition and notifies listeners. Repeated notifications for the same state are ignored.
+func (c *Client) setLive(channel string, live bool) bool {
+	channel = strings.ToLower(channel)
+
+	c.liveMu.Lock()
+	if c.live[channel] == live {
+		c.liveMu.Unlock()
+		return false
+	}
+	c.live[channel] = live
+	// Queued under the lock so listeners see transitions in the order they were recorded
+	if c.liveChanges != nil {
+		c.liveChanges <- liveChange{channel: channel, live: live}
+	}
+	c.liveMu.Unlock()
+
+	c.logger.Info("Stream state changed", zap.String("channel", channel), zap.Bool("live", live))
+	return true
+}
+
+// handleStreamOnline marks the channel live, publishes the lifecycle event and sends the go-live announcement.
+func (c *Client) handleStreamOnline(messageID string, e helix.EventSubStreamOnlineEvent) *events.Event {
+	if !c.setLive(e.BroadcasterUserLogin, true) {
+		return nil
+	}
+
+	if c.config.GoLiveMessage != "" && c.chat != nil {
+		message := strings.NewReplacer("${channel}", e.BroadcasterUserName).Replace(c.config.GoLiveMessage)
+		go c.chat.Say(e.BroadcasterUserLogin, message)
+	}
+
+	return events.New(events.PlatformTwitch, events.TypeTwitchStreamOnline, messageID,
+		&events.Actor{ID: e.BroadcasterUserID, Login: e.BroadcasterUserLogin, DisplayName: e.BroadcasterUserName},
+		events.StreamStatusData{Channel: e.BroadcasterUserLogin, Live: true, StartedAt: timePtr(e.StartedAt)})
+}
+
+// handleStreamOffline marks the channel offline and publishes the lifecycle event.
+func (c *Client) handleStreamOffline(messageID string, e helix.EventSubStreamOfflineEvent) *events.Event {
+	if !c.setLive(e.BroadcasterUserLogin, false) {
+		return nil
+	}
+
+	return events.New(events.PlatformTwitch, events.TypeTwitchStreamOffline, messageID,
+		&events.Actor{ID: e.BroadcasterUserID, Login: e.BroadcasterUserLogin, DisplayName: e.BroadcasterUserName},
+		events.StreamStatusData{Channel: e.BroadcasterUserLogin, Live: false})
+}
@@ -0,0 +1,194 @@
+package twitch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"VLX_Robot/internal/commands"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+)
+
+// manifestName is the per-folder metadata file (commands.yml, commands.yaml or commands.json).
+const manifestName = "commands"
+
+// metadataExts are the accepted metadata file extensions, in lookup order.
+var metadataExts = []string{".yml", ".yaml", ".json"}
+
+// commandMetadata is the optional metadata of a media command, read from a sidecar
+// next to the file (hello.yml for hello.mp3) or from the folder manifest.
+type commandMetadata struct {
+	Aliases     []string `yaml:"aliases" json:"aliases"`
+	Volume      int      `yaml:"volume" json:"volume"` // 1-100
+	Description string   `yaml:"description" json:"description"`
+	Cooldown    int      `yaml:"cooldown" json:"cooldown"` // Seconds
+	Cost        int      `yaml:"cost" json:"cost"`         // Channel Points
+	Hidden      bool     `yaml:"hidden" json:"hidden"`
+	Role        string   `yaml:"role" json:"role"` // Overrides the role of the folder
+
+	// Random-pick folders only
+	Weights  map[string]int `yaml:"weights" json:"weights"`     // Clip file name -> relative chance (default 1)
+	NoRepeat int            `yaml:"no_repeat" json:"no_repeat"` // Clips that must play before one repeats (default 1)
+}
+
+// isMetadataFile reports whether a file in a command folder holds metadata rather than media.
+func isMetadataFile(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, e := range metadataExts {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeMetadata parses a YAML or JSON metadata file into out.
+func decodeMetadata(path string, out interface{}) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(out)
+	} else {
+		err = yaml.UnmarshalStrict(raw, out)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid metadata in %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// loadManifest reads the folder manifest, keyed by lowercase command name. A missing manifest is not an error.
+func loadManifest(folder string, logger *zap.Logger) map[string]commandMetadata {
+	for _, ext := range metadataExts {
+		path := filepath.Join(folder, manifestName+ext)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+
+		var raw map[string]commandMetadata
+		if err := decodeMetadata(path, &raw); err != nil {
+			logger.Warn("Ignoring command manifest", zap.String("path", path), zap.Error(err))
+			return nil
+		}
+		manifest := make(map[string]commandMetadata, len(raw))
+		for name, meta := range raw {
+			manifest[normalizeCommandName(name)] = meta
+		}
+		return manifest
+	}
+	return nil
+}
+
+// loadSidecar reads the metadata file sharing the media file's base name, if any.
+func loadSidecar(folder, base string, logger *zap.Logger) (commandMetadata, bool) {
+	for _, ext := range metadataExts {
+		path := filepath.Join(folder, base+ext)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+
+		var meta commandMetadata
+		if err := decodeMetadata(path, &meta); err != nil {
+			logger.Warn("Ignoring command metadata", zap.String("path", path), zap.Error(err))
+			return commandMetadata{}, false
+		}
+		return meta, true
+	}
+	return commandMetadata{}, false
+}
+
+// apply copies the metadata onto cmd, dropping out-of-range values.
+func (m commandMetadata) apply(cmd *commands.MediaCommand, logger *zap.Logger) {
+	for _, alias := range m.Aliases {
+		if alias = normalizeCommandName(alias); alias != "" && alias != cmd.Name {
+			cmd.Aliases = append(cmd.Aliases, alias)
+		}
+	}
+	if m.Volume >= 0 && m.Volume <= 100 {
+		cmd.Volume = m.Volume
+	} else {
+		logger.Warn("Command volume must be between 1 and 100, ignoring", zap.String("command", cmd.Name), zap.Int("volume", m.Volume))
+	}
+	if m.Cooldown > 0 {
+		cmd.Cooldown = time.Duration(m.Cooldown) * time.Second
+	}
+	if m.Cost > 0 {
+		cmd.Cost = m.Cost
+	}
+	cmd.Description = strings.TrimSpace(m.Description)
+	cmd.Hidden = m.Hidden
+	if m.Role != "" {
+		cmd.Permission = strings.ToLower(m.Role)
+	}
+
+	if len(m.Weights) > 0 && len(cmd.Clips) == 0 {
+		logger.Warn("Clip weights only apply to random-pick folders, ignoring", zap.String("command", cmd.Name))
+	}
+	for name, weight := range m.Weights {
+		matched := false
+		for i := range cmd.Clips {
+			if !strings.EqualFold(path.Base(cmd.Clips[i].Filename), name) {
+				continue
+			}
+			matched = true
+			if weight >= 1 {
+				cmd.Clips[i].Weight = weight
+			}
+		}
+		if len(cmd.Clips) > 0 && (!matched || weight < 1) {
+			logger.Warn("Invalid clip weight, ignoring", zap.String("command", cmd.Name), zap.String("clip", name), zap.Int("weight", weight))
+		}
+	}
+	if m.NoRepeat > 0 {
+		cmd.NoRepeat = m.NoRepeat
+	}
+}
+
+// registerAliases adds an entry for every alias that does not clash with a command, a built-in or an earlier alias.
+func registerAliases(found AudioCommandsMap, logger *zap.Logger) {
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cmd := found[name]
+		if len(cmd.Aliases) == 0 {
+			continue
+		}
+
+		var accepted []string
+		for _, alias := range cmd.Aliases {
+			if _, taken := found[alias]; taken || reservedCommands[alias] {
+				logger.Warn("Alias conflicts with another command, skipping", zap.String("command", name), zap.String("alias", alias))
+				continue
+			}
+			accepted = append(accepted, alias)
+			found[alias] = cmd // Placeholder so later aliases see it taken; replaced below
+		}
+
+		cmd.Aliases = accepted
+		found[name] = cmd
+		for _, alias := range accepted {
+			found[alias] = cmd
+		}
+	}
+}
+
+// normalizeCommandName lowercases a command name and strips the "!" prefix.
+func normalizeCommandName(name string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "!"))
+}
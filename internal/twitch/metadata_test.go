@@ -0,0 +1,184 @@
+package twitch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"VLX_Robot/internal/commands"
+	"VLX_Robot/internal/config"
+	"VLX_Robot/internal/events"
+	"VLX_Robot/internal/websocket"
+
+	"github.com/gempir/go-twitch-irc/v4"
+	"go.uber.org/zap"
+)
+
+func writeLibrary(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for path, content := range files {
+		full := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestScanAudioCommandsMetadata(t *testing.T) {
+	dir := writeLibrary(t, map[string]string{
+		"everyone/airhorn.mp3":   "dummy",
+		"everyone/airhorn.yml":   "aliases: [horn, \"!AH\", hello]\nvolume: 40\ndescription: Classic air horn\ncooldown: 30\n",
+		"everyone/hello.mp3":     "dummy",
+		"everyone/secret.wav":    "dummy",
+		"everyone/commands.json": `{"secret": {"hidden": true, "volume": 80}, "hello": {"description": "From the manifest"}}`,
+		"vips/drop.mp4":          "dummy",
+		"vips/drop.yaml":         "cost: 500\nvolume: 250\n",
+		"vips/broken.mp3":        "dummy",
+		"vips/broken.yml":        "colume: 10\n",
+	})
+
+	cmds, err := ScanAudioCommands(dir, commands.DefaultRoles(), zap.NewNop())
+	if err != nil {
+		t.Fatalf("ScanAudioCommands failed: %v", err)
+	}
+
+	airhorn := cmds["airhorn"]
+	if airhorn.Volume != 40 || airhorn.Description != "Classic air horn" || airhorn.Cooldown != 30*time.Second {
+		t.Errorf("Sidecar not applied: %+v", airhorn)
+	}
+	// "hello" is a command of its own, so only two aliases are registered
+	if len(airhorn.Aliases) != 2 || cmds["horn"].Name != "airhorn" || cmds["ah"].Filename != "everyone/airhorn.mp3" {
+		t.Errorf("Unexpected aliases: %+v", airhorn.Aliases)
+	}
+	if cmds["hello"].Name != "hello" || cmds["hello"].Description != "From the manifest" {
+		t.Errorf("Alias must not shadow a command: %+v", cmds["hello"])
+	}
+	if secret := cmds["secret"]; !secret.Hidden || secret.Volume != 80 {
+		t.Errorf("Manifest not applied: %+v", secret)
+	}
+	if drop := cmds["drop"]; drop.Cost != 500 || drop.Volume != 0 || drop.Permission != PermissionVIP {
+		t.Errorf("Expected cost and an ignored volume, got %+v", drop)
+	}
+	if broken, ok := cmds["broken"]; !ok || broken.Volume != 0 {
+		t.Errorf("Invalid metadata must leave the command playable, got %+v", broken)
+	}
+	if _, ok := cmds["commands"]; ok {
+		t.Error("Manifest must not become a command")
+	}
+	if len(cmds) != 7 {
+		t.Errorf("Expected 5 commands and 2 aliases, got %d", len(cmds))
+	}
+}
+
+func TestScanAudioCommandsRandomFolder(t *testing.T) {
+	dir := writeLibrary(t, map[string]string{
+		"everyone/fart/short.mp3": "dummy",
+		"everyone/fart/long.wav":  "dummy",
+		"everyone/fart/clip.mp4":  "dummy",
+		"everyone/fart/notes.txt": "dummy",
+		"everyone/fart.yml":       "weights: {LONG.wav: 5, missing.mp3: 2}\nno_repeat: 2\n",
+		"everyone/empty/x.txt":    "dummy",
+	})
+
+	cmds, err := ScanAudioCommands(dir, commands.DefaultRoles(), zap.NewNop())
+	if err != nil {
+		t.Fatalf("ScanAudioCommands failed: %v", err)
+	}
+	if _, ok := cmds["empty"]; ok {
+		t.Error("Folders without media must be skipped")
+	}
+
+	fart, ok := cmds["fart"]
+	if !ok || fart.Filename != "everyone/fart" || fart.NoRepeat != 2 || len(fart.Clips) != 3 {
+		t.Fatalf("Unexpected folder command: %+v", fart)
+	}
+	weights := make(map[string]int)
+	for _, clip := range fart.Clips {
+		weights[clip.Filename] = clip.Weight
+	}
+	if weights["everyone/fart/long.wav"] != 5 || weights["everyone/fart/short.mp3"] != 1 || weights["everyone/fart/clip.mp4"] != 1 {
+		t.Errorf("Unexpected weights %v", weights)
+	}
+}
+
+func TestMediaCommandMetadataInChat(t *testing.T) {
+	logger := zap.NewNop()
+	hub := websocket.NewHub(logger)
+	media := AudioCommandsMap{
+		"airhorn": {Name: "airhorn", Filename: "everyone/airhorn.mp3", Permission: PermissionEveryone, MediaType: "audio",
+			Aliases: []string{"horn"}, Volume: 40, Description: "Classic air horn", Cooldown: time.Minute},
+		"horn": {Name: "airhorn", Filename: "everyone/airhorn.mp3", Permission: PermissionEveryone, MediaType: "audio",
+			Aliases: []string{"horn"}, Volume: 40, Description: "Classic air horn", Cooldown: time.Minute},
+		"drop":   {Filename: "everyone/drop.mp4", Permission: PermissionEveryone, MediaType: "video", Cost: 500},
+		"secret": {Filename: "everyone/secret.wav", Permission: PermissionEveryone, Hidden: true},
+	}
+	registry := commands.NewRegistry(media, commands.NewCooldowns(commands.CooldownPolicy{}))
+	c := NewChatClient(config.TwitchChatConfig{}, nil, hub, registry, nil, logger)
+
+	// Folder commands name the chosen file
+	registry.SetMedia(map[string]commands.MediaCommand{"fart": {Filename: "everyone/fart", Permission: PermissionEveryone,
+		Clips: []commands.Clip{{Filename: "everyone/fart/long.wav", MediaType: "audio", Weight: 1}}}})
+	go c.handlePrivateMessage(twitch.PrivateMessage{Channel: "streamer", User: twitch.User{ID: "9", Badges: map[string]int{}}, Message: "!fart"})
+	select {
+	case evt := <-hub.Broadcast:
+		if data := evt.Data.(events.SoundCommandData); data.Command != "fart" || data.Filename != "everyone/fart/long.wav" || data.MediaType != "audio" {
+			t.Errorf("Unexpected payload: %+v", data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for folder command")
+	}
+	registry.SetMedia(media)
+
+	viewer := twitch.User{ID: "1", Name: "viewer", Badges: map[string]int{}}
+	send := func(user twitch.User, text string) {
+		c.handlePrivateMessage(twitch.PrivateMessage{ID: "m", Channel: "streamer", User: user, Message: text})
+	}
+
+	// The alias plays the command with its volume
+	go send(viewer, "!horn")
+	select {
+	case evt := <-hub.Broadcast:
+		data := evt.Data.(events.SoundCommandData)
+		if data.Command != "airhorn" || data.Volume != 40 {
+			t.Errorf("Unexpected payload: %+v", data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for sound command")
+	}
+
+	// The command cooldown from the metadata covers the alias too
+	other := twitch.User{ID: "2", Name: "other", Badges: map[string]int{}}
+	send(other, "!airhorn")
+	select {
+	case evt := <-hub.Broadcast:
+		t.Fatalf("Unexpected broadcast: %+v", evt)
+	default:
+	}
+	if stats := registry.Stats("airhorn"); stats.Uses != 1 || stats.Rejected != 1 {
+		t.Errorf("Expected alias and command to share stats, got %+v", stats)
+	}
+
+	// A cost is only displayed: the role decides who can play the command
+	go send(viewer, "!drop")
+	select {
+	case <-hub.Broadcast:
+	case <-time.After(time.Second):
+		t.Fatal("Expected viewers to play a command with a cost")
+	}
+
+	if got := c.commandDetails("!HORN"); got != "!airhorn: Classic air horn | Aliases: !horn | Cooldown: 60s" {
+		t.Errorf("Unexpected details %q", got)
+	}
+	if got := c.commandDetails("drop"); got != "!drop | Cost: 500 pts" {
+		t.Errorf("Unexpected details %q", got)
+	}
+	if got := c.commandDetails("secret"); got != "" {
+		t.Errorf("Hidden commands must not be described, got %q", got)
+	}
+}
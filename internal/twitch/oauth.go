@@ -0,0 +1,89 @@
+package twitch
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"VLX_Robot/internal/database"
+
+	"github.com/nicklaw5/helix/v2"
+	"go.uber.org/zap"
+)
+
+// CallbackPath is the OAuth redirect endpoint served by the main server.
+const CallbackPath = "/auth/twitch/callback"
+
+// RequiredScopes returns the OAuth scopes needed by the EventSub subscriptions this client creates.
+func (c *Client) RequiredScopes() []string {
+	scopes := []string{
+		"moderator:read:followers",   // channel.follow v2
+		"channel:read:subscriptions", // channel.subscribe, .gift, .message
+		"bits:read",                  // channel.cheer
+		"channel:read:hype_train",
+		"channel:read:polls",
+		"channel:read:predictions",
+	}
+	if len(c.config.Rewards) > 0 {
+		scopes = append(scopes, "channel:manage:redemptions") // Redemption events and status updates
+	}
+	if c.whispers {
+		scopes = append(scopes, "user:manage:whispers") // Whispered cooldown notices
+	}
+	return scopes
+}
+
+// AuthorizationURL returns the Twitch consent page URL for the authorization-code flow.
+func (c *Client) AuthorizationURL(state string) string {
+	return c.helix.GetAuthorizationURL(&helix.AuthorizationURLParams{
+		ResponseType: "code",
+		Scopes:       c.RequiredScopes(),
+		State:        state,
+		ForceVerify:  true,
+	})
+}
+
+// CompleteAuthorization exchanges an authorization code and stores the resulting user token.
+// Only monitored broadcasters may authorize; the login of the token owner is returned.
+func (c *Client) CompleteAuthorization(code string) (string, error) {
+	if code == "" {
+		return "", errors.New("missing authorization code")
+	}
+
+	token, err := c.helix.RequestUserAccessToken(code)
+	if err != nil {
+		return "", fmt.Errorf("code exchange failed: %w", err)
+	}
+	if token.StatusCode >= 400 {
+		return "", fmt.Errorf("api token error %d: %s", token.StatusCode, token.ErrorMessage)
+	}
+
+	// Resolve the token owner
+	valid, validation, err := c.helix.ValidateToken(token.Data.AccessToken)
+	if err != nil {
+		return "", fmt.Errorf("token validation failed: %w", err)
+	}
+	if !valid || validation.Data.UserID == "" {
+		return "", errors.New("received token is not valid")
+	}
+
+	login := validation.Data.Login
+	if _, ok := c.channels[strings.ToLower(login)]; !ok {
+		return login, fmt.Errorf("account %q is not a monitored channel", login)
+	}
+
+	creds := &database.TwitchCredentials{
+		UserID:       validation.Data.UserID,
+		AccessToken:  token.Data.AccessToken,
+		RefreshToken: token.Data.RefreshToken,
+		ExpiresAt:    time.Now().UTC().Add(time.Second * time.Duration(token.Data.ExpiresIn)),
+	}
+	if err := c.db.UpsertTwitchCredentials(creds); err != nil {
+		return login, fmt.Errorf("db update failed: %w", err)
+	}
+
+	c.trackUserTokens(creds.UserID)
+	c.logger.Info("Twitch user token authorized", zap.String("login", login), zap.Strings("scopes", token.Data.Scopes))
+	return login, nil
+}
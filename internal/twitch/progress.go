@@ -0,0 +1,191 @@
+package twitch
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"VLX_Robot/internal/events"
+
+	"github.com/nicklaw5/helix/v2"
+)
+
+// Hype Train, Poll and Prediction EventSub types
+const (
+	EventSubHypeTrainBegin     = "channel.hype_train.begin"
+	EventSubHypeTrainProgress  = "channel.hype_train.progress"
+	EventSubHypeTrainEnd       = "channel.hype_train.end"
+	EventSubPollBegin          = "channel.poll.begin"
+	EventSubPollProgress       = "channel.poll.progress"
+	EventSubPollEnd            = "channel.poll.end"
+	EventSubPredictionBegin    = "channel.prediction.begin"
+	EventSubPredictionProgress = "channel.prediction.progress"
+	EventSubPredictionLock     = "channel.prediction.lock"
+	EventSubPredictionEnd      = "channel.prediction.end"
+)
+
+// progressEventTypes lists the subscriptions that feed the progress overlay.
+var progressEventTypes = []string{
+	EventSubHypeTrainBegin, EventSubHypeTrainProgress, EventSubHypeTrainEnd,
+	EventSubPollBegin, EventSubPollProgress, EventSubPollEnd,
+	EventSubPredictionBegin, EventSubPredictionProgress, EventSubPredictionLock, EventSubPredictionEnd,
+}
+
+// progressEvent converts a Hype Train, Poll or Prediction notification into an Event envelope.
+func progressEvent(messageID, eventType string, eventData json.RawMessage) (*events.Event, error) {
+	switch eventType {
+	case EventSubHypeTrainBegin:
+		// helix's begin event has no level field, but Twitch sends one (a train can start above level 1)
+		var e struct {
+			helix.EventSubHypeTrainBeginEvent
+			Level int `json:"level"`
+		}
+		if err := json.Unmarshal(eventData, &e); err != nil {
+			return nil, err
+		}
+		if e.Level == 0 {
+			e.Level = 1
+		}
+		return events.New(events.PlatformTwitch, events.TypeTwitchHypeTrain, messageID, contributionActor(e.LastContribution),
+			events.HypeTrainData{
+				Phase:            events.PhaseBegin,
+				Level:            e.Level,
+				Total:            e.Total,
+				Progress:         e.Progress,
+				Goal:             e.Goal,
+				TopContributions: contributions(e.TopContributions),
+				ExpiresAt:        timePtr(e.ExpiresAt),
+			}), nil
+
+	case EventSubHypeTrainProgress:
+		var e helix.EventSubHypeTrainProgressEvent
+		if err := json.Unmarshal(eventData, &e); err != nil {
+			return nil, err
+		}
+		return events.New(events.PlatformTwitch, events.TypeTwitchHypeTrain, messageID, contributionActor(e.LastContribution),
+			events.HypeTrainData{
+				Phase:            events.PhaseProgress,
+				Level:            e.Level,
+				Total:            e.Total,
+				Progress:         e.Progress,
+				Goal:             e.Goal,
+				TopContributions: contributions(e.TopContributions),
+				ExpiresAt:        timePtr(e.ExpiresAt),
+			}), nil
+
+	case EventSubHypeTrainEnd:
+		var e helix.EventSubHypeTrainEndEvent
+		if err := json.Unmarshal(eventData, &e); err != nil {
+			return nil, err
+		}
+		return events.New(events.PlatformTwitch, events.TypeTwitchHypeTrain, messageID, nil,
+			events.HypeTrainData{
+				Phase:            events.PhaseEnd,
+				Level:            e.Level,
+				Total:            e.Total,
+				TopContributions: contributions(e.TopContributions),
+				EndedAt:          timePtr(e.EndedAt),
+			}), nil
+
+	case EventSubPollBegin:
+		var e helix.EventSubChannelPollBeginEvent
+		if err := json.Unmarshal(eventData, &e); err != nil {
+			return nil, err
+		}
+		return events.New(events.PlatformTwitch, events.TypeTwitchPoll, messageID, nil,
+			events.PollData{Phase: events.PhaseBegin, PollID: e.ID, Title: e.Title, Choices: pollChoices(e.Choices), EndsAt: timePtr(e.EndsAt)}), nil
+
+	case EventSubPollProgress:
+		var e helix.EventSubChannelPollProgressEvent
+		if err := json.Unmarshal(eventData, &e); err != nil {
+			return nil, err
+		}
+		return events.New(events.PlatformTwitch, events.TypeTwitchPoll, messageID, nil,
+			events.PollData{Phase: events.PhaseProgress, PollID: e.ID, Title: e.Title, Choices: pollChoices(e.Choices), EndsAt: timePtr(e.EndsAt)}), nil
+
+	case EventSubPollEnd:
+		var e helix.EventSubChannelPollEndEvent
+		if err := json.Unmarshal(eventData, &e); err != nil {
+			return nil, err
+		}
+		return events.New(events.PlatformTwitch, events.TypeTwitchPoll, messageID, nil,
+			events.PollData{Phase: events.PhaseEnd, PollID: e.ID, Title: e.Title, Choices: pollChoices(e.Choices), Status: e.Status}), nil
+
+	case EventSubPredictionBegin, EventSubPredictionProgress:
+		var e helix.EventSubChannelPredictionBeginEvent
+		if err := json.Unmarshal(eventData, &e); err != nil {
+			return nil, err
+		}
+		phase := events.PhaseBegin
+		if eventType == EventSubPredictionProgress {
+			phase = events.PhaseProgress
+		}
+		return events.New(events.PlatformTwitch, events.TypeTwitchPrediction, messageID, nil,
+			events.PredictionData{Phase: phase, PredictionID: e.ID, Title: e.Title, Outcomes: predictionOutcomes(e.Outcomes), LocksAt: timePtr(e.LocksAt)}), nil
+
+	case EventSubPredictionLock:
+		var e helix.EventSubChannelPredictionLockEvent
+		if err := json.Unmarshal(eventData, &e); err != nil {
+			return nil, err
+		}
+		return events.New(events.PlatformTwitch, events.TypeTwitchPrediction, messageID, nil,
+			events.PredictionData{Phase: events.PhaseLock, PredictionID: e.ID, Title: e.Title, Outcomes: predictionOutcomes(e.Outcomes)}), nil
+
+	case EventSubPredictionEnd:
+		var e helix.EventSubChannelPredictionEndEvent
+		if err := json.Unmarshal(eventData, &e); err != nil {
+			return nil, err
+		}
+		return events.New(events.PlatformTwitch, events.TypeTwitchPrediction, messageID, nil,
+			events.PredictionData{
+				Phase:            events.PhaseEnd,
+				PredictionID:     e.ID,
+				Title:            e.Title,
+				Outcomes:         predictionOutcomes(e.Outcomes),
+				WinningOutcomeID: e.WinningOutcomeID,
+				Status:           e.Status,
+			}), nil
+	}
+	return nil, fmt.Errorf("unsupported progress event %q", eventType)
+}
+
+// contributionActor returns the user behind a hype train contribution, or nil if unknown.
+func contributionActor(c helix.EventSubContribution) *events.Actor {
+	if c.UserID == "" {
+		return nil
+	}
+	return &events.Actor{ID: c.UserID, Login: c.UserLogin, DisplayName: c.UserName}
+}
+
+func contributions(in []helix.EventSubContribution) []events.Contribution {
+	out := make([]events.Contribution, 0, len(in))
+	for _, c := range in {
+		out = append(out, events.Contribution{UserID: c.UserID, UserLogin: c.UserLogin, DisplayName: c.UserName, Type: c.Type, Total: c.Total})
+	}
+	return out
+}
+
+func pollChoices(in []helix.PollChoice) []events.PollChoice {
+	out := make([]events.PollChoice, 0, len(in))
+	for _, c := range in {
+		out = append(out, events.PollChoice{ID: c.ID, Title: c.Title, Votes: c.Votes})
+	}
+	return out
+}
+
+func predictionOutcomes(in []helix.EventSubOutcome) []events.PredictionOutcome {
+	out := make([]events.PredictionOutcome, 0, len(in))
+	for _, o := range in {
+		out = append(out, events.PredictionOutcome{ID: o.ID, Title: o.Title, Color: o.Color, Users: o.Users, ChannelPoints: o.ChannelPoints})
+	}
+	return out
+}
+
+// timePtr returns nil for a zero timestamp so it is omitted from the payload.
+func timePtr(t helix.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	v := t.Time.UTC()
+	return &v
+}
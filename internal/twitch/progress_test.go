@@ -0,0 +1,86 @@
+package twitch
+
+import (
+	"encoding/json"
+	"testing"
+
+	"VLX_Robot/internal/events"
+)
+
+func TestProgressEventHypeTrain(t *testing.T) {
+	raw := json.RawMessage(`{"total":137,"progress":137,"goal":500,
+		"top_contributions":[{"user_id":"1","user_login":"fan","user_name":"Fan","type":"bits","total":100}],
+		"last_contribution":{"user_id":"2","user_login":"sub","user_name":"Sub","type":"subscription","total":37},
+		"expires_at":"2024-01-01T20:05:00Z"}`)
+
+	evt, err := progressEvent("msg-1", EventSubHypeTrainBegin, raw)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if evt.Type != events.TypeTwitchHypeTrain || evt.Topic() != events.TopicProgress {
+		t.Errorf("Unexpected type/topic: %s/%s", evt.Type, evt.Topic())
+	}
+	if evt.Actor == nil || evt.Actor.DisplayName != "Sub" {
+		t.Errorf("Expected last contributor as actor, got %+v", evt.Actor)
+	}
+	data := evt.Data.(events.HypeTrainData)
+	if data.Phase != events.PhaseBegin || data.Level != 1 || data.Progress != 137 || data.Goal != 500 {
+		t.Errorf("Unexpected hype train data: %+v", data)
+	}
+	if len(data.TopContributions) != 1 || data.TopContributions[0].DisplayName != "Fan" {
+		t.Errorf("Unexpected top contributions: %+v", data.TopContributions)
+	}
+	if data.ExpiresAt == nil || data.EndedAt != nil {
+		t.Errorf("Expected expires_at only, got %v / %v", data.ExpiresAt, data.EndedAt)
+	}
+
+	// A train can begin above level 1: the level comes from the payload
+	evt, err = progressEvent("msg-2", EventSubHypeTrainBegin, json.RawMessage(`{"level":3,"total":900,"progress":100,"goal":800}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if level := evt.Data.(events.HypeTrainData).Level; level != 3 {
+		t.Errorf("Expected level 3 from the payload, got %d", level)
+	}
+}
+
+func TestProgressEventPoll(t *testing.T) {
+	raw := json.RawMessage(`{"id":"poll-1","title":"Best map?","status":"completed",
+		"choices":[{"id":"a","title":"Dust","votes":7},{"id":"b","title":"Inferno","votes":3}]}`)
+
+	evt, err := progressEvent("msg-2", EventSubPollEnd, raw)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data := evt.Data.(events.PollData)
+	if evt.Type != events.TypeTwitchPoll || data.Phase != events.PhaseEnd || data.Status != "completed" {
+		t.Errorf("Unexpected poll event: %+v", data)
+	}
+	if len(data.Choices) != 2 || data.Choices[0].Votes != 7 || data.Choices[1].Title != "Inferno" {
+		t.Errorf("Unexpected choices: %+v", data.Choices)
+	}
+}
+
+func TestProgressEventPrediction(t *testing.T) {
+	raw := json.RawMessage(`{"id":"pred-1","title":"Win?","winning_outcome_id":"o1","status":"resolved",
+		"outcomes":[{"id":"o1","title":"Yes","color":"blue","users":4,"channel_points":1200},{"id":"o2","title":"No","color":"pink","users":1,"channel_points":50}]}`)
+
+	evt, err := progressEvent("msg-3", EventSubPredictionEnd, raw)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data := evt.Data.(events.PredictionData)
+	if evt.Type != events.TypeTwitchPrediction || data.Phase != events.PhaseEnd || data.WinningOutcomeID != "o1" {
+		t.Errorf("Unexpected prediction event: %+v", data)
+	}
+	if len(data.Outcomes) != 2 || data.Outcomes[0].ChannelPoints != 1200 {
+		t.Errorf("Unexpected outcomes: %+v", data.Outcomes)
+	}
+
+	if _, err := progressEvent("msg-4", EventSubPredictionLock, json.RawMessage(`{"id":"pred-1"}`)); err != nil {
+		t.Errorf("Lock event failed: %v", err)
+	}
+	if _, err := progressEvent("msg-5", "channel.unknown", json.RawMessage(`{}`)); err == nil {
+		t.Error("Expected error for unsupported type")
+	}
+}
@@ -0,0 +1,250 @@
+package twitch
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nicklaw5/helix/v2"
+	"go.uber.org/zap"
+)
+
+// reconcileInterval is how often webhook subscriptions are compared with Helix.
+const reconcileInterval = 30 * time.Minute
+
+// Subscription statuses reported by Helix
+const (
+	SubscriptionEnabled             = "enabled"
+	SubscriptionVerificationPending = "webhook_callback_verification_pending"
+)
+
+// subscriptionSpec is one EventSub type/version the bot wants for each channel.
+type subscriptionSpec struct {
+	Type    string
+	Version string
+}
+
+// subscriptionKey identifies a desired subscription: one per type and broadcaster.
+type subscriptionKey struct {
+	Type   string
+	UserID string
+}
+
+// reconcilePlan is the outcome of comparing Helix with the desired state.
+type reconcilePlan struct {
+	remove  map[string]string                              // Subscription ID -> reason
+	keep    map[subscriptionKey]helix.EventSubSubscription // Healthy subscriptions
+	missing []subscriptionKey                              // To be (re)created
+}
+
+// eventGroups maps the event names used in the channel config to EventSub types.
+var eventGroups = map[string][]string{
+	"follow":      {EventSubFollow},
+	"raid":        {EventSubRaid},
+	"subscribe":   {EventSubSubscribe},
+	"gift_sub":    {EventSubSubGift},
+	"resubscribe": {EventSubSubMessage},
+	"cheer":       {EventSubCheer},
+	"stream":      {EventSubStreamOnline, EventSubStreamOffline},
+	"redemption":  {EventSubRedemption},
+	"hype_train":  {EventSubHypeTrainBegin, EventSubHypeTrainProgress, EventSubHypeTrainEnd},
+	"poll":        {EventSubPollBegin, EventSubPollProgress, EventSubPollEnd},
+	"prediction":  {EventSubPredictionBegin, EventSubPredictionProgress, EventSubPredictionLock, EventSubPredictionEnd},
+}
+
+// desiredSubscriptions returns the subscriptions to create for one channel, filtered by its enabled event groups.
+func (c *Client) desiredSubscriptions(login string) []subscriptionSpec {
+	specs := []subscriptionSpec{
+		{EventSubFollow, "2"},
+		{EventSubRaid, "1"},
+		{EventSubSubscribe, "1"},
+		{EventSubSubGift, "1"},
+		{EventSubSubMessage, "1"},
+		{EventSubCheer, "1"},
+		{EventSubStreamOnline, "1"},
+		{EventSubStreamOffline, "1"},
+	}
+	if len(c.config.Rewards) > 0 {
+		specs = append(specs, subscriptionSpec{EventSubRedemption, "1"})
+	}
+	for _, eventType := range progressEventTypes {
+		specs = append(specs, subscriptionSpec{eventType, "1"})
+	}
+
+	channel, ok := c.channels[strings.ToLower(login)]
+	if !ok || len(channel.Events) == 0 {
+		return specs
+	}
+
+	enabled := make(map[string]bool)
+	for _, group := range channel.Events {
+		for _, eventType := range eventGroups[group] {
+			enabled[eventType] = true
+		}
+	}
+	filtered := specs[:0]
+	for _, spec := range specs {
+		if enabled[spec.Type] {
+			filtered = append(filtered, spec)
+		}
+	}
+	return filtered
+}
+
+// subscriptionOwner returns the broadcaster a subscription belongs to (the raid target for raids).
+func subscriptionOwner(sub helix.EventSubSubscription) string {
+	if sub.Type == EventSubRaid {
+		return sub.Condition.ToBroadcasterUserID
+	}
+	return sub.Condition.BroadcasterUserID
+}
+
+// planReconciliation decides which webhook subscriptions to delete, keep and create.
+// Subscriptions on another transport are ignored; those on an old callback URL are orphans.
+func planReconciliation(remote []helix.EventSubSubscription, desired []subscriptionKey, callback string) reconcilePlan {
+	plan := reconcilePlan{
+		remove: make(map[string]string),
+		keep:   make(map[subscriptionKey]helix.EventSubSubscription),
+	}
+
+	wanted := make(map[subscriptionKey]bool, len(desired))
+	for _, key := range desired {
+		wanted[key] = true
+	}
+
+	for _, sub := range remote {
+		if sub.Transport.Method != TransportWebhook {
+			continue
+		}
+		key := subscriptionKey{Type: sub.Type, UserID: subscriptionOwner(sub)}
+
+		switch {
+		case sub.Transport.Callback != callback:
+			plan.remove[sub.ID] = "orphaned callback " + sub.Transport.Callback
+		case !wanted[key]:
+			plan.remove[sub.ID] = "not desired"
+		case sub.Status != SubscriptionEnabled && sub.Status != SubscriptionVerificationPending:
+			plan.remove[sub.ID] = "status " + sub.Status
+		default:
+			if _, dup := plan.keep[key]; dup {
+				plan.remove[sub.ID] = "duplicate"
+				continue
+			}
+			plan.keep[key] = sub
+		}
+	}
+
+	for _, key := range desired {
+		if _, ok := plan.keep[key]; !ok {
+			plan.missing = append(plan.missing, key)
+		}
+	}
+	return plan
+}
+
+// runReconciler reconciles webhook subscriptions now and then every reconcileInterval.
+func (c *Client) runReconciler(users []helix.User, transport helix.EventSubTransport) {
+	ticker := time.NewTicker(reconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.reconcileSubscriptions(users, transport); err != nil {
+			c.logger.Error("EventSub reconciliation failed, trusting local state", zap.Error(err))
+			for _, user := range users {
+				c.subscribeChannel(c.helix, user, transport)
+			}
+		}
+		<-ticker.C
+	}
+}
+
+// reconcileSubscriptions compares Helix with the desired state, fixes the difference and updates the DB.
+func (c *Client) reconcileSubscriptions(users []helix.User, transport helix.EventSubTransport) error {
+	remote, err := c.listSubscriptions()
+	if err != nil {
+		return err
+	}
+
+	versions := make(map[string]string)
+	var desired []subscriptionKey
+	for _, user := range users {
+		for _, spec := range c.desiredSubscriptions(user.Login) {
+			desired = append(desired, subscriptionKey{Type: spec.Type, UserID: user.ID})
+			versions[spec.Type] = spec.Version
+		}
+	}
+
+	plan := planReconciliation(remote, desired, transport.Callback)
+
+	for id, reason := range plan.remove {
+		c.logger.Info("Removing EventSub subscription", zap.String("id", id), zap.String("reason", reason))
+		resp, err := c.helix.RemoveEventSubSubscription(id)
+		if err != nil || (resp.StatusCode >= 400 && resp.StatusCode != http.StatusNotFound) {
+			c.logger.Warn("Failed to remove EventSub subscription", zap.String("id", id), zap.Error(err))
+		}
+		c.db.DeleteSubscription(id)
+	}
+
+	// Mirror healthy subscriptions into the DB so subscribeToEvent can rely on it
+	for key, sub := range plan.keep {
+		c.syncSubscriptionRow(key, sub)
+	}
+
+	for _, key := range plan.missing {
+		// Drop the stale row first, otherwise subscribeToEvent would skip the creation
+		if row, err := c.db.GetSubscription(key.UserID, key.Type); err == nil {
+			c.db.DeleteSubscription(row.ID)
+		}
+		c.logger.Info("Creating missing EventSub subscription", zap.String("type", key.Type), zap.String("user_id", key.UserID))
+		if key.Type == EventSubRaid {
+			c.subscribeToRaidEvent(c.helix, key.UserID, transport)
+		} else {
+			c.subscribeToEvent(c.helix, key.UserID, key.Type, versions[key.Type], transport)
+		}
+	}
+
+	c.logger.Info("EventSub subscriptions reconciled",
+		zap.Int("remote", len(remote)),
+		zap.Int("kept", len(plan.keep)),
+		zap.Int("removed", len(plan.remove)),
+		zap.Int("created", len(plan.missing)),
+	)
+	return nil
+}
+
+// listSubscriptions returns every subscription owned by the app, following pagination.
+func (c *Client) listSubscriptions() ([]helix.EventSubSubscription, error) {
+	var all []helix.EventSubSubscription
+	params := &helix.EventSubSubscriptionsParams{}
+	for {
+		resp, err := c.helix.GetEventSubSubscriptions(params)
+		if err != nil {
+			return nil, fmt.Errorf("list subscriptions: %w", err)
+		}
+		if resp.StatusCode >= 400 {
+			return nil, fmt.Errorf("list subscriptions: api status %d: %s", resp.StatusCode, resp.ErrorMessage)
+		}
+		all = append(all, resp.Data.EventSubSubscriptions...)
+
+		cursor := resp.Data.Pagination.Cursor
+		if cursor == "" || cursor == params.After {
+			return all, nil
+		}
+		params.After = cursor
+	}
+}
+
+// syncSubscriptionRow makes the DB row for key match the Helix subscription.
+func (c *Client) syncSubscriptionRow(key subscriptionKey, sub helix.EventSubSubscription) {
+	row, err := c.db.GetSubscription(key.UserID, key.Type)
+	if err == nil && row.ID == sub.ID && row.Status == sub.Status {
+		return
+	}
+	if err == nil {
+		c.db.DeleteSubscription(row.ID)
+	}
+	if err := c.saveSubscriptionToDB(key.UserID, key.Type, &sub); err != nil {
+		c.logger.Warn("Failed to sync subscription to DB", zap.String("type", key.Type), zap.Error(err))
+	}
+}
@@ -0,0 +1,89 @@
+package twitch
+
+import (
+	"testing"
+
+	"VLX_Robot/internal/config"
+
+	"github.com/nicklaw5/helix/v2"
+)
+
+func TestPlanReconciliation(t *testing.T) {
+	const callback = "https://bot.example.com/webhooks/twitch"
+	webhook := func(id, eventType, userID, status, cb string) helix.EventSubSubscription {
+		sub := helix.EventSubSubscription{
+			ID:        id,
+			Type:      eventType,
+			Status:    status,
+			Transport: helix.EventSubTransport{Method: TransportWebhook, Callback: cb},
+		}
+		if eventType == EventSubRaid {
+			sub.Condition.ToBroadcasterUserID = userID
+		} else {
+			sub.Condition.BroadcasterUserID = userID
+		}
+		return sub
+	}
+
+	remote := []helix.EventSubSubscription{
+		webhook("ok-follow", EventSubFollow, "1", SubscriptionEnabled, callback),
+		webhook("ok-raid", EventSubRaid, "1", SubscriptionEnabled, callback),
+		webhook("dup-follow", EventSubFollow, "1", SubscriptionEnabled, callback),
+		webhook("failed-cheer", EventSubCheer, "1", "webhook_callback_verification_failed", callback),
+		webhook("orphan", EventSubSubscribe, "1", SubscriptionEnabled, "https://old.example.com/webhooks/twitch"),
+		webhook("unwanted", EventSubFollow, "999", SubscriptionEnabled, callback),
+		webhook("pending", EventSubSubGift, "1", SubscriptionVerificationPending, callback),
+		{ID: "ws", Type: EventSubCheer, Status: SubscriptionEnabled, Transport: helix.EventSubTransport{Method: TransportWebSocket}},
+	}
+	desired := []subscriptionKey{
+		{EventSubFollow, "1"},
+		{EventSubRaid, "1"},
+		{EventSubCheer, "1"},
+		{EventSubSubscribe, "1"},
+		{EventSubSubGift, "1"},
+	}
+
+	plan := planReconciliation(remote, desired, callback)
+
+	for _, id := range []string{"dup-follow", "failed-cheer", "orphan", "unwanted"} {
+		if _, ok := plan.remove[id]; !ok {
+			t.Errorf("Expected %s to be removed", id)
+		}
+	}
+	if len(plan.remove) != 4 {
+		t.Errorf("Expected 4 removals, got %v", plan.remove)
+	}
+
+	if plan.keep[subscriptionKey{EventSubFollow, "1"}].ID != "ok-follow" ||
+		plan.keep[subscriptionKey{EventSubRaid, "1"}].ID != "ok-raid" ||
+		plan.keep[subscriptionKey{EventSubSubGift, "1"}].ID != "pending" {
+		t.Errorf("Unexpected kept subscriptions: %+v", plan.keep)
+	}
+
+	missing := map[subscriptionKey]bool{}
+	for _, key := range plan.missing {
+		missing[key] = true
+	}
+	if len(missing) != 2 || !missing[subscriptionKey{EventSubCheer, "1"}] || !missing[subscriptionKey{EventSubSubscribe, "1"}] {
+		t.Errorf("Unexpected missing subscriptions: %+v", plan.missing)
+	}
+}
+
+func TestDesiredSubscriptionsPerChannel(t *testing.T) {
+	c := &Client{channels: map[string]config.TwitchChannelConfig{
+		"main":   {Name: "Main"},
+		"friend": {Name: "Friend", Events: []string{"raid", "stream"}},
+	}}
+
+	if got := len(c.desiredSubscriptions("Main")); got != 8+len(progressEventTypes) {
+		t.Errorf("Expected every subscription for a channel without filter, got %d", got)
+	}
+
+	types := map[string]bool{}
+	for _, spec := range c.desiredSubscriptions("friend") {
+		types[spec.Type] = true
+	}
+	if len(types) != 3 || !types[EventSubRaid] || !types[EventSubStreamOnline] || !types[EventSubStreamOffline] {
+		t.Errorf("Unexpected filtered subscriptions: %v", types)
+	}
+}
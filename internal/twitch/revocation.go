@@ -0,0 +1,122 @@
+package twitch
+
+import (
+	"fmt"
+	"time"
+
+	"VLX_Robot/internal/events"
+
+	"github.com/nicklaw5/helix/v2"
+	"go.uber.org/zap"
+)
+
+// Revocation reasons sent by Twitch in subscription.status
+const (
+	RevocationUserRemoved                  = "user_removed"
+	RevocationAuthorizationRevoked         = "authorization_revoked"
+	RevocationNotificationFailuresExceeded = "notification_failures_exceeded"
+	RevocationVersionRemoved               = "version_removed"
+)
+
+const (
+	// resubscribeBaseDelay is the wait before the first resubscription attempt; it doubles on each failure.
+	resubscribeBaseDelay = 30 * time.Second
+	maxResubscribeDelay  = 10 * time.Minute
+	maxResubscribeTries  = 6
+)
+
+// isRecoverableRevocation reports whether resubscribing can fix the revocation.
+// Removed users, revoked authorizations and removed versions need an operator.
+func isRecoverableRevocation(reason string) bool {
+	return reason == RevocationNotificationFailuresExceeded
+}
+
+// handleRevocation cleans up after Twitch revokes a subscription, then resubscribes or alerts the operator.
+func (c *Client) handleRevocation(sub helix.EventSubSubscription, transport helix.EventSubTransport) {
+	c.logger.Warn("Subscription revoked", zap.String("id", sub.ID), zap.String("type", sub.Type), zap.String("status", sub.Status))
+	c.db.DeleteSubscription(sub.ID)
+
+	if !isRecoverableRevocation(sub.Status) {
+		c.raiseOperatorAlert(sub, revocationHint(sub.Status))
+		return
+	}
+	go c.resubscribe(sub, transport)
+}
+
+// resubscribe recreates a revoked subscription, backing off between failed attempts.
+func (c *Client) resubscribe(sub helix.EventSubSubscription, transport helix.EventSubTransport) {
+	delay := resubscribeBaseDelay
+	for attempt := 1; attempt <= maxResubscribeTries; attempt++ {
+		time.Sleep(delay)
+
+		err := c.recreateSubscription(sub, transport)
+		if err == nil {
+			c.logger.Info("Resubscribed after revocation", zap.String("type", sub.Type), zap.Int("attempt", attempt))
+			return
+		}
+		c.logger.Warn("Resubscription failed", zap.String("type", sub.Type), zap.Int("attempt", attempt), zap.Error(err))
+		delay = min(delay*2, maxResubscribeDelay)
+	}
+	c.raiseOperatorAlert(sub, fmt.Sprintf("resubscription failed %d times", maxResubscribeTries))
+}
+
+// recreateSubscription creates the subscription again on the same transport and records it.
+func (c *Client) recreateSubscription(sub helix.EventSubSubscription, transport helix.EventSubTransport) error {
+	userID := subscriptionOwner(sub)
+	create := func(api *helix.Client) error {
+		var newSub *helix.EventSubSubscription
+		var err error
+		if sub.Type == EventSubRaid {
+			newSub, err = c.createRaidSubscription(api, userID, transport)
+		} else {
+			newSub, err = c.createSubscription(api, userID, sub.Type, sub.Version, transport)
+		}
+		if err != nil {
+			return err
+		}
+		if transport.Method == TransportWebhook {
+			return c.saveSubscriptionToDB(userID, sub.Type, newSub)
+		}
+		return nil
+	}
+
+	// WebSocket subscriptions must be created with the user token, on the session that is current now:
+	// a session_reconnect during the backoff replaces the one the revocation arrived on
+	if transport.Method == TransportWebSocket {
+		transport = c.webSocketTransport()
+		return c.withUserToken(userID, create)
+	}
+	return create(c.helix)
+}
+
+// raiseOperatorAlert logs a revocation that needs manual action and publishes it on the status topic.
+func (c *Client) raiseOperatorAlert(sub helix.EventSubSubscription, hint string) {
+	c.logger.Error("OPERATOR ACTION REQUIRED: EventSub subscription lost",
+		zap.String("type", sub.Type),
+		zap.String("reason", sub.Status),
+		zap.String("user_id", subscriptionOwner(sub)),
+		zap.String("hint", hint),
+	)
+	if c.hub == nil {
+		return
+	}
+	c.hub.Publish(events.New(events.PlatformSystem, events.TypeSystemAlert, "", nil, events.SystemAlertData{
+		Severity: "error",
+		Source:   "eventsub",
+		Message:  fmt.Sprintf("Subscription %s revoked (%s): %s", sub.Type, sub.Status, hint),
+	}))
+}
+
+// revocationHint tells the operator how to recover from an unrecoverable revocation.
+func revocationHint(reason string) string {
+	switch reason {
+	case RevocationAuthorizationRevoked:
+		return "the broadcaster revoked the app authorization; authorize again via /auth/twitch/login"
+	case RevocationUserRemoved:
+		return "the Twitch user no longer exists; update channel_name"
+	case RevocationVersionRemoved:
+		return "the subscription version is no longer supported; update the bot"
+	default:
+		return "unknown revocation reason"
+	}
+}
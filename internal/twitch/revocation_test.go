@@ -0,0 +1,58 @@
+package twitch
+
+import (
+	"testing"
+	"time"
+
+	"VLX_Robot/internal/database"
+	"VLX_Robot/internal/events"
+	"VLX_Robot/internal/websocket"
+
+	"github.com/nicklaw5/helix/v2"
+	"go.uber.org/zap"
+)
+
+func TestIsRecoverableRevocation(t *testing.T) {
+	tests := map[string]bool{
+		RevocationNotificationFailuresExceeded: true,
+		RevocationUserRemoved:                  false,
+		RevocationAuthorizationRevoked:         false,
+		RevocationVersionRemoved:               false,
+	}
+	for reason, want := range tests {
+		if got := isRecoverableRevocation(reason); got != want {
+			t.Errorf("isRecoverableRevocation(%q) = %v, want %v", reason, got, want)
+		}
+	}
+}
+
+func TestHandleRevocationAlertsOperator(t *testing.T) {
+	logger := zap.NewNop()
+	hub := websocket.NewHub(logger)
+	store := database.NewMemoryStore()
+	store.CreateSubscription(&database.TwitchSubscription{ID: "sub-1", UserID: "123", EventType: EventSubFollow, Status: SubscriptionEnabled})
+
+	// helix is nil: an unrecoverable revocation must not try to resubscribe
+	c := &Client{db: store, hub: hub, logger: logger}
+	sub := helix.EventSubSubscription{
+		ID:        "sub-1",
+		Type:      EventSubFollow,
+		Status:    RevocationAuthorizationRevoked,
+		Condition: helix.EventSubCondition{BroadcasterUserID: "123"},
+	}
+	go c.handleRevocation(sub, c.webhookTransport())
+
+	select {
+	case evt := <-hub.Broadcast:
+		data := evt.Data.(events.SystemAlertData)
+		if evt.Type != events.TypeSystemAlert || evt.Topic() != events.TopicStatus || data.Severity != "error" {
+			t.Errorf("Unexpected alert: %+v", evt)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for operator alert")
+	}
+
+	if _, err := store.GetSubscription("123", EventSubFollow); err == nil {
+		t.Error("Expected revoked subscription to be removed from the DB")
+	}
+}
@@ -0,0 +1,226 @@
+package twitch
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"VLX_Robot/internal/config"
+	"VLX_Robot/internal/events"
+
+	"github.com/nicklaw5/helix/v2"
+	"go.uber.org/zap"
+)
+
+// Reward action types
+const (
+	RewardActionMedia = "media" // Play a file from static/chat
+	RewardActionAlert = "alert" // Show a redemption alert
+	RewardActionChat  = "chat"  // Send a chat message
+)
+
+// Redemption statuses accepted by Helix
+const (
+	RedemptionFulfilled = "FULFILLED"
+	RedemptionCanceled  = "CANCELED"
+)
+
+// manageableTTL is how long the list of rewards this Client ID may update is cached.
+const manageableTTL = time.Hour
+
+type manageableRewards struct {
+	ids       map[string]bool
+	fetchedAt time.Time
+}
+
+// ChatSender sends messages to a Twitch chat channel.
+type ChatSender interface {
+	Say(channel, message string)
+}
+
+// SetChatSender wires the IRC bot used by chat-based reward actions.
+func (c *Client) SetChatSender(sender ChatSender) {
+	c.chat = sender
+}
+
+// findReward returns the configured action for a reward, matching by ID first, then by title.
+func (c *Client) findReward(rewardID, title string) (config.RewardConfig, bool) {
+	for _, r := range c.config.Rewards {
+		if r.ID != "" && r.ID == rewardID {
+			return r, true
+		}
+	}
+	for _, r := range c.config.Rewards {
+		if r.ID == "" && strings.EqualFold(r.Title, title) {
+			return r, true
+		}
+	}
+	return config.RewardConfig{}, false
+}
+
+// handleRedemption runs the configured action and marks the redemption fulfilled or cancelled (refunded).
+func (c *Client) handleRedemption(messageID string, e helix.EventSubChannelPointsCustomRewardRedemptionEvent) {
+	reward, ok := c.findReward(e.Reward.ID, e.Reward.Title)
+	if !ok {
+		c.logger.Info("Unmapped reward redeemed", zap.String("reward", e.Reward.Title), zap.String("user", e.UserName))
+		return
+	}
+
+	status := RedemptionFulfilled
+	if err := c.executeReward(messageID, reward, e); err != nil {
+		c.logger.Warn("Reward action failed, cancelling redemption", zap.String("reward", e.Reward.Title), zap.Error(err))
+		status = RedemptionCanceled
+	} else {
+		c.logger.Info("Reward redeemed", zap.String("reward", e.Reward.Title), zap.String("user", e.UserName), zap.String("action", reward.Action))
+	}
+
+	manageable, err := c.isManageable(e.BroadcasterUserID, e.Reward.ID)
+	if err != nil {
+		c.logger.Warn("Could not check whether the reward is manageable, updating anyway", zap.String("reward", e.Reward.Title), zap.Error(err))
+	} else if !manageable {
+		c.warnUnmanageable(e)
+		return
+	}
+
+	if err := c.updateRedemptionStatus(e, status); err != nil {
+		c.logger.Error("Failed to update redemption status", zap.String("id", e.ID), zap.String("status", status), zap.Error(err))
+	}
+}
+
+// executeReward performs the action bound to a reward.
+func (c *Client) executeReward(messageID string, reward config.RewardConfig, e helix.EventSubChannelPointsCustomRewardRedemptionEvent) error {
+	actor := &events.Actor{ID: e.UserID, Login: e.UserLogin, DisplayName: e.UserName}
+
+	switch reward.Action {
+	case RewardActionMedia:
+		mediaType, err := mediaTypeFor(reward.File)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(filepath.Join(c.mediaDir, filepath.FromSlash(reward.File))); err != nil {
+			return fmt.Errorf("media file unavailable: %w", err)
+		}
+		c.hub.Publish(events.New(events.PlatformTwitch, events.TypeSoundCommand, messageID, actor,
+			events.SoundCommandData{Command: e.Reward.Title, Filename: reward.File, MediaType: mediaType}).FromChannel(e.BroadcasterUserLogin))
+
+	case RewardActionAlert:
+		c.hub.Publish(events.New(events.PlatformTwitch, events.TypeTwitchRedemption, messageID, actor,
+			events.RedemptionData{
+				RewardID:    e.Reward.ID,
+				RewardTitle: e.Reward.Title,
+				Cost:        e.Reward.Cost,
+				UserInput:   e.UserInput,
+				Message:     expandRewardMessage(reward.Message, e),
+			}).FromChannel(e.BroadcasterUserLogin))
+
+	case RewardActionChat:
+		if c.chat == nil {
+			return errors.New("chat bot is not running")
+		}
+		if reward.Message == "" {
+			return errors.New("chat action has no message")
+		}
+		c.chat.Say(e.BroadcasterUserLogin, expandRewardMessage(reward.Message, e))
+
+	default:
+		return fmt.Errorf("unknown reward action %q", reward.Action)
+	}
+	return nil
+}
+
+// isManageable reports whether this Client ID may update redemptions of a reward. Twitch only allows it for
+// rewards created through the API with the same Client ID, not for rewards created in the dashboard.
+func (c *Client) isManageable(broadcasterID, rewardID string) (bool, error) {
+	c.rewardMu.Lock()
+	cached, ok := c.manageable[broadcasterID]
+	c.rewardMu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < manageableTTL {
+		return cached.ids[rewardID], nil
+	}
+
+	entry := manageableRewards{ids: make(map[string]bool)}
+	err := c.withUserToken(broadcasterID, func(api *helix.Client) error {
+		resp, err := api.GetCustomRewards(&helix.GetCustomRewardsParams{BroadcasterID: broadcasterID, OnlyManageableRewards: true})
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("api status %d: %s", resp.StatusCode, resp.ErrorMessage)
+		}
+		for _, reward := range resp.Data.ChannelCustomRewards {
+			entry.ids[reward.ID] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("get custom rewards: %w", err)
+	}
+
+	entry.fetchedAt = time.Now()
+	c.rewardMu.Lock()
+	if c.manageable == nil {
+		c.manageable = make(map[string]manageableRewards)
+	}
+	c.manageable[broadcasterID] = entry
+	c.rewardMu.Unlock()
+	return entry.ids[rewardID], nil
+}
+
+// warnUnmanageable reports once per reward that its redemptions stay in the queue.
+func (c *Client) warnUnmanageable(e helix.EventSubChannelPointsCustomRewardRedemptionEvent) {
+	c.rewardMu.Lock()
+	defer c.rewardMu.Unlock()
+	if c.unmanagedWarned[e.Reward.ID] {
+		return
+	}
+	if c.unmanagedWarned == nil {
+		c.unmanagedWarned = make(map[string]bool)
+	}
+	c.unmanagedWarned[e.Reward.ID] = true
+	c.logger.Warn("Reward was not created by this Client ID, its redemptions cannot be fulfilled or refunded by the bot",
+		zap.String("reward", e.Reward.Title),
+		zap.String("reward_id", e.Reward.ID),
+		zap.String("channel", e.BroadcasterUserLogin),
+		zap.String("hint", "recreate the reward through the Helix API with this app's Client ID, or manage the queue by hand"),
+	)
+}
+
+// updateRedemptionStatus marks a redemption via Helix using the token of the channel it was redeemed in.
+func (c *Client) updateRedemptionStatus(e helix.EventSubChannelPointsCustomRewardRedemptionEvent, status string) error {
+	return c.withUserToken(e.BroadcasterUserID, func(api *helix.Client) error {
+		resp, err := api.UpdateChannelCustomRewardsRedemptionStatus(&helix.UpdateChannelCustomRewardsRedemptionStatusParams{
+			ID:            e.ID,
+			BroadcasterID: e.BroadcasterUserID,
+			RewardID:      e.Reward.ID,
+			Status:        status,
+		})
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("api status %d: %s", resp.StatusCode, resp.ErrorMessage)
+		}
+		return nil
+	})
+}
+
+// expandRewardMessage substitutes ${user} and ${input} in a reward message.
+func expandRewardMessage(message string, e helix.EventSubChannelPointsCustomRewardRedemptionEvent) string {
+	return strings.NewReplacer("${user}", e.UserName, "${input}", e.UserInput).Replace(message)
+}
+
+// mediaTypeFor maps a file extension to the overlay media type.
+func mediaTypeFor(filename string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".mp3", ".wav", ".ogg":
+		return "audio", nil
+	case ".mp4", ".webm":
+		return "video", nil
+	default:
+		return "", fmt.Errorf("unsupported media file %q", filename)
+	}
+}
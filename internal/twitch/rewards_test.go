@@ -0,0 +1,141 @@
+package twitch
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"VLX_Robot/internal/config"
+	"VLX_Robot/internal/events"
+	"VLX_Robot/internal/websocket"
+
+	"github.com/nicklaw5/helix/v2"
+	"go.uber.org/zap"
+)
+
+type fakeChatSender struct {
+	mu      sync.Mutex
+	channel string
+	message string
+}
+
+func (f *fakeChatSender) Say(channel, message string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.channel = channel
+	f.message = message
+}
+
+func (f *fakeChatSender) last() (channel, message string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.channel, f.message
+}
+
+func TestFindReward(t *testing.T) {
+	c := &Client{config: config.TwitchConfig{Rewards: []config.RewardConfig{
+		{Title: "Hydrate", Action: RewardActionMedia},
+		{ID: "abc", Title: "Hydrate", Action: RewardActionChat},
+	}}}
+
+	if r, ok := c.findReward("abc", "Hydrate"); !ok || r.Action != RewardActionChat {
+		t.Errorf("Expected ID match to win, got %+v", r)
+	}
+	if r, ok := c.findReward("other", "hydrate"); !ok || r.Action != RewardActionMedia {
+		t.Errorf("Expected case-insensitive title match, got %+v", r)
+	}
+	if _, ok := c.findReward("other", "Unknown"); ok {
+		t.Error("Expected no match for unknown reward")
+	}
+}
+
+func TestExecuteReward(t *testing.T) {
+	logger := zap.NewNop()
+	hub := websocket.NewHub(logger)
+	chat := &fakeChatSender{}
+
+	mediaDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(mediaDir, "everyone"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(mediaDir, "everyone", "yes.mp3"), []byte("dummy"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Client{hub: hub, chat: chat, mediaDir: mediaDir, logger: logger}
+	redemption := helix.EventSubChannelPointsCustomRewardRedemptionEvent{
+		BroadcasterUserLogin: "streamer",
+		UserName:             "Viewer",
+		UserInput:            "hello",
+		Reward:               helix.EventSubReward{ID: "r1", Title: "Yes"},
+	}
+
+	// Media: existing file is published as a sound command
+	go func() {
+		if err := c.executeReward("m1", config.RewardConfig{Action: RewardActionMedia, File: "everyone/yes.mp3"}, redemption); err != nil {
+			t.Errorf("Media action failed: %v", err)
+		}
+	}()
+	select {
+	case evt := <-hub.Broadcast:
+		data := evt.Data.(events.SoundCommandData)
+		if evt.Type != events.TypeSoundCommand || data.Filename != "everyone/yes.mp3" || data.MediaType != "audio" {
+			t.Errorf("Unexpected media event: %+v", evt)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for media broadcast")
+	}
+
+	// Media: missing file fails so the redemption is refunded
+	if err := c.executeReward("m2", config.RewardConfig{Action: RewardActionMedia, File: "everyone/missing.mp3"}, redemption); err == nil {
+		t.Error("Expected error for missing media file")
+	}
+
+	// Chat: message is expanded and sent to the broadcaster channel
+	if err := c.executeReward("m3", config.RewardConfig{Action: RewardActionChat, Message: "${user} says ${input}"}, redemption); err != nil {
+		t.Fatalf("Chat action failed: %v", err)
+	}
+	if channel, message := chat.last(); channel != "streamer" || message != "Viewer says hello" {
+		t.Errorf("Unexpected chat message %q on %q", message, channel)
+	}
+
+	// Unknown action fails
+	if err := c.executeReward("m4", config.RewardConfig{Action: "dance"}, redemption); err == nil {
+		t.Error("Expected error for unknown action")
+	}
+}
+
+func TestHandleRedemptionUnmanageableReward(t *testing.T) {
+	chat := &fakeChatSender{}
+	// db is nil: a status update would panic, so returning proves it was skipped
+	c := &Client{
+		chat:   chat,
+		logger: zap.NewNop(),
+		config: config.TwitchConfig{Rewards: []config.RewardConfig{{Title: "Hydrate", Action: RewardActionChat, Message: "Drink up ${user}"}}},
+		manageable: map[string]manageableRewards{
+			"b1": {ids: map[string]bool{"api-reward": true}, fetchedAt: time.Now()},
+		},
+	}
+	redemption := helix.EventSubChannelPointsCustomRewardRedemptionEvent{
+		BroadcasterUserID:    "b1",
+		BroadcasterUserLogin: "streamer",
+		UserName:             "Viewer",
+		Reward:               helix.EventSubReward{ID: "dashboard-reward", Title: "Hydrate"},
+	}
+
+	c.handleRedemption("m1", redemption)
+	c.handleRedemption("m2", redemption)
+
+	// The action still runs
+	if _, message := chat.last(); message != "Drink up Viewer" {
+		t.Errorf("Unexpected chat message %q", message)
+	}
+	if !c.unmanagedWarned["dashboard-reward"] || len(c.unmanagedWarned) != 1 {
+		t.Errorf("Expected a single warning for the reward, got %v", c.unmanagedWarned)
+	}
+	if ok, err := c.isManageable("b1", "api-reward"); err != nil || !ok {
+		t.Errorf("Expected the API reward to be manageable, got %v (%v)", ok, err)
+	}
+}
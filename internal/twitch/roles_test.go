@@ -0,0 +1,140 @@
+package twitch
+
+import (
+	"testing"
+	"time"
+
+	"VLX_Robot/internal/commands"
+	"VLX_Robot/internal/config"
+
+	"github.com/gempir/go-twitch-irc/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type fakeFollowChecker struct {
+	since time.Time
+	calls []string
+}
+
+func (f *fakeFollowChecker) FollowedAt(broadcasterID, userID string) (time.Time, bool, error) {
+	f.calls = append(f.calls, broadcasterID+"/"+userID)
+	return f.since, !f.since.IsZero(), nil
+}
+
+func TestChatRoles(t *testing.T) {
+	roles, err := commands.RolesFromConfig([]config.RoleConfig{
+		{Name: "veterans", Twitch: config.RoleTwitchConfig{MinMonths: 12}},
+		{Name: "followers", Twitch: config.RoleTwitchConfig{FollowDays: 30}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	follows := &fakeFollowChecker{since: time.Now().Add(-60 * 24 * time.Hour)}
+	c := &ChatClient{roles: roles, follows: follows, logger: zap.NewNop()}
+
+	message := func(badgeInfo string) twitch.PrivateMessage {
+		return twitch.PrivateMessage{
+			RoomID: "b1",
+			User:   twitch.User{ID: "u1", Badges: map[string]int{"subscriber": 12}},
+			Tags:   map[string]string{"badge-info": badgeInfo},
+		}
+	}
+
+	if !c.hasPermission(message("subscriber/14"), "veterans") {
+		t.Error("Expected 14 months to pass min_months 12")
+	}
+	if c.hasPermission(message("subscriber/11"), "veterans") {
+		t.Error("Expected 11 months to fail min_months 12")
+	}
+	if !c.hasPermission(message("founder/20"), "veterans") {
+		t.Error("Expected founder months to count")
+	}
+	if len(follows.calls) != 0 {
+		t.Errorf("Unexpected follow lookups %v", follows.calls)
+	}
+
+	if !c.hasPermission(message(""), "followers") || len(follows.calls) != 1 || follows.calls[0] != "b1/u1" {
+		t.Errorf("Expected one follow lookup in the message's channel, got %v", follows.calls)
+	}
+	follows.since = time.Time{}
+	if c.hasPermission(message(""), "followers") {
+		t.Error("Non-followers must not hold the followers role")
+	}
+}
+
+// blockingFollowChecker never answers before release, like a stalled Helix request.
+type blockingFollowChecker struct {
+	release chan struct{}
+}
+
+func (f *blockingFollowChecker) FollowedAt(broadcasterID, userID string) (time.Time, bool, error) {
+	<-f.release
+	return time.Now().Add(-60 * 24 * time.Hour), true, nil
+}
+
+func TestChatRolesSlowFollowLookup(t *testing.T) {
+	roles, err := commands.RolesFromConfig([]config.RoleConfig{{Name: "followers", Twitch: config.RoleTwitchConfig{FollowDays: 30}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	follows := &blockingFollowChecker{release: make(chan struct{})}
+	defer close(follows.release)
+	c := &ChatClient{roles: roles, follows: follows, logger: zap.NewNop()}
+
+	start := time.Now()
+	allowed := c.hasPermission(twitch.PrivateMessage{RoomID: "b1", User: twitch.User{ID: "u1", Badges: map[string]int{}}}, "followers")
+	if allowed {
+		t.Error("Expected a pending follow lookup to deny for now")
+	}
+	if elapsed := time.Since(start); elapsed > followLookupTimeout+500*time.Millisecond {
+		t.Errorf("Follow lookup held up chat for %v", elapsed)
+	}
+}
+
+func TestScanAudioCommandsRoleFolders(t *testing.T) {
+	roles, err := commands.RolesFromConfig([]config.RoleConfig{{Name: "founders"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := writeLibrary(t, map[string]string{
+		"founders/thanks.mp3": "dummy",
+		"everyone/gated.mp3":  "dummy",
+		"everyone/gated.yml":  "role: Founders\n",
+		"unknown/skip.mp3":    "dummy",
+	})
+
+	cmds, err := ScanAudioCommands(dir, roles, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmds["thanks"].Permission != "founders" || cmds["gated"].Permission != "founders" {
+		t.Errorf("Unexpected roles: %+v", cmds)
+	}
+	if _, ok := cmds["skip"]; ok {
+		t.Error("Folders without a role must not be scanned")
+	}
+}
+
+func TestScanAudioCommandsDuplicateAcrossFolders(t *testing.T) {
+	dir := writeLibrary(t, map[string]string{
+		"vips/hello.mp3":        "dummy",
+		"everyone/hello.mp3":    "dummy",
+		"subscribers/hello.mp3": "dummy",
+	})
+
+	// Map iteration is random: repeat so a non-deterministic scan shows up
+	for i := 0; i < 20; i++ {
+		core, logs := observer.New(zap.WarnLevel)
+		cmds, err := ScanAudioCommands(dir, commands.DefaultRoles(), zap.New(core))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cmds["hello"].Filename != "everyone/hello.mp3" || cmds["hello"].Permission != PermissionEveryone {
+			t.Fatalf("Scan %d: expected everyone/hello.mp3 to win, got %+v", i, cmds["hello"])
+		}
+		if n := logs.FilterMessage("Duplicate command detected, skipping").Len(); n != 2 {
+			t.Fatalf("Scan %d: expected 2 shadowed duplicates logged, got %d", i, n)
+		}
+	}
+}
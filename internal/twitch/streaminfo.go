@@ -0,0 +1,79 @@
+package twitch
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"VLX_Robot/internal/commands"
+
+	"github.com/nicklaw5/helix/v2"
+)
+
+// streamStatusTTL bounds how often template variables (${uptime}, ${game}) query Helix.
+const streamStatusTTL = 30 * time.Second
+
+type cachedStreamStatus struct {
+	status    commands.StreamStatus
+	fetchedAt time.Time
+}
+
+// StreamStatus returns the live state, start time and category of a channel (login).
+// Results are cached briefly so busy chats do not hammer Helix.
+func (c *Client) StreamStatus(channel string) (commands.StreamStatus, error) {
+	channel = strings.ToLower(channel)
+
+	c.streamMu.Lock()
+	cached, ok := c.streamCache[channel]
+	c.streamMu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < streamStatusTTL {
+		return cached.status, nil
+	}
+
+	status, err := c.fetchStreamStatus(channel)
+	if err != nil {
+		return status, err
+	}
+
+	c.streamMu.Lock()
+	if c.streamCache == nil {
+		c.streamCache = make(map[string]cachedStreamStatus)
+	}
+	c.streamCache[channel] = cachedStreamStatus{status: status, fetchedAt: time.Now()}
+	c.streamMu.Unlock()
+	return status, nil
+}
+
+// fetchStreamStatus reads the stream from Helix, falling back to the channel information for the category when offline.
+func (c *Client) fetchStreamStatus(channel string) (commands.StreamStatus, error) {
+	var status commands.StreamStatus
+
+	streams, err := c.helix.GetStreams(&helix.StreamsParams{UserLogins: []string{channel}})
+	if err != nil {
+		return status, fmt.Errorf("get streams: %w", err)
+	}
+	if streams.StatusCode != http.StatusOK {
+		return status, fmt.Errorf("get streams: api status %d: %s", streams.StatusCode, streams.ErrorMessage)
+	}
+	if len(streams.Data.Streams) > 0 && streams.Data.Streams[0].Type == "live" {
+		s := streams.Data.Streams[0]
+		return commands.StreamStatus{Live: true, StartedAt: s.StartedAt, Game: s.GameName}, nil
+	}
+
+	users, err := c.helix.GetUsers(&helix.UsersParams{Logins: []string{channel}})
+	if err != nil || users.StatusCode != http.StatusOK || len(users.Data.Users) == 0 {
+		return status, fmt.Errorf("could not resolve channel %s", channel)
+	}
+	info, err := c.helix.GetChannelInformation(&helix.GetChannelInformationParams{BroadcasterIDs: []string{users.Data.Users[0].ID}})
+	if err != nil {
+		return status, fmt.Errorf("get channel information: %w", err)
+	}
+	if info.StatusCode != http.StatusOK {
+		return status, fmt.Errorf("get channel information: api status %d: %s", info.StatusCode, info.ErrorMessage)
+	}
+	if len(info.Data.Channels) > 0 {
+		status.Game = info.Data.Channels[0].GameName
+	}
+	return status, nil
+}
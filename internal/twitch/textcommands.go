@@ -0,0 +1,241 @@
+package twitch
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"VLX_Robot/internal/commands"
+	"VLX_Robot/internal/database"
+
+	"github.com/gempir/go-twitch-irc/v4"
+	"go.uber.org/zap"
+)
+
+// Chat commands that manage text commands (moderators and broadcaster only)
+const (
+	CommandAdd    = "addcom"
+	CommandEdit   = "editcom"
+	CommandDelete = "delcom"
+)
+
+// maxResponseLength is the Twitch chat message limit.
+const maxResponseLength = 500
+
+// reservedCommands cannot be used as text command names.
+var reservedCommands = map[string]bool{
+	"commands":    true,
+	"comandi":     true,
+	CommandAdd:    true,
+	CommandEdit:   true,
+	CommandDelete: true,
+}
+
+// textCommandArgs is the parsed form of "!addcom !name [-perm=level] [-cd=seconds] response".
+type textCommandArgs struct {
+	Name       string
+	Response   string
+	Permission string // Empty = unchanged (edit) or everyone (add)
+	Cooldown   int    // -1 = unchanged (edit) or global cooldown (add)
+}
+
+// parseTextCommandArgs parses the arguments of !addcom and !editcom.
+// Options must come right after the command name; -perm takes any role name.
+func parseTextCommandArgs(args []string, roles *commands.Roles) (textCommandArgs, error) {
+	parsed := textCommandArgs{Cooldown: -1}
+	if len(args) == 0 {
+		return parsed, errors.New("missing command name")
+	}
+	parsed.Name = strings.ToLower(strings.TrimPrefix(args[0], "!"))
+	if parsed.Name == "" {
+		return parsed, errors.New("missing command name")
+	}
+
+	rest := args[1:]
+	for len(rest) > 0 && strings.HasPrefix(rest[0], "-") {
+		key, value, ok := strings.Cut(strings.TrimPrefix(rest[0], "-"), "=")
+		if !ok {
+			break
+		}
+		switch key {
+		case "perm":
+			value = strings.ToLower(value)
+			if !roles.Has(value) {
+				return parsed, fmt.Errorf("unknown permission %q (%s)", value, strings.Join(roles.Names(), ", "))
+			}
+			parsed.Permission = value
+		case "cd":
+			seconds, err := strconv.Atoi(value)
+			if err != nil || seconds < 0 {
+				return parsed, fmt.Errorf("invalid cooldown %q", value)
+			}
+			parsed.Cooldown = seconds
+		default:
+			return parsed, fmt.Errorf("unknown option -%s", key)
+		}
+		rest = rest[1:]
+	}
+
+	parsed.Response = strings.Join(rest, " ")
+	if len(parsed.Response) > maxResponseLength {
+		return parsed, fmt.Errorf("response is longer than %d characters", maxResponseLength)
+	}
+	return parsed, nil
+}
+
+// isModerator reports whether the user may manage commands.
+func isModerator(user twitch.User) bool {
+	_, isBroadcaster := user.Badges["broadcaster"]
+	_, isMod := user.Badges["moderator"]
+	return isBroadcaster || isMod
+}
+
+// manageTextCommand handles !addcom, !editcom and !delcom and returns the chat reply.
+func (c *ChatClient) manageTextCommand(message twitch.PrivateMessage, action string, args []string) string {
+	if c.db == nil || !isModerator(message.User) {
+		return ""
+	}
+
+	var reply string
+	var err error
+	switch action {
+	case CommandAdd:
+		reply, err = c.addTextCommand(message.User.Name, args)
+	case CommandEdit:
+		reply, err = c.editTextCommand(message.User.Name, args)
+	case CommandDelete:
+		reply, err = c.deleteTextCommand(args)
+	}
+	if err != nil {
+		return fmt.Sprintf("@%s %v", message.User.DisplayName, err)
+	}
+	c.logger.Info("Text command updated", zap.String("action", action), zap.String("user", message.User.Name), zap.Strings("args", args))
+	return reply
+}
+
+func (c *ChatClient) addTextCommand(user string, args []string) (string, error) {
+	parsed, err := parseTextCommandArgs(args, c.roles)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Response == "" {
+		return "", errors.New("usage: !addcom !name [-perm=role] [-cd=seconds] response")
+	}
+	if reservedCommands[parsed.Name] {
+		return "", fmt.Errorf("!%s is a built-in command", parsed.Name)
+	}
+	if _, ok := c.registry.Media(parsed.Name); ok {
+		return "", fmt.Errorf("!%s is already a media command", parsed.Name)
+	}
+	if _, err := c.db.GetTextCommand(parsed.Name); err == nil {
+		return "", fmt.Errorf("!%s already exists, use !editcom", parsed.Name)
+	} else if err != sql.ErrNoRows {
+		return "", errors.New("could not save the command")
+	}
+
+	cmd := &database.TextCommand{
+		Name:       parsed.Name,
+		Response:   parsed.Response,
+		Permission: parsed.Permission,
+		UpdatedBy:  user,
+		UpdatedAt:  time.Now().UTC(),
+	}
+	if cmd.Permission == "" {
+		cmd.Permission = PermissionEveryone
+	}
+	if parsed.Cooldown > 0 {
+		cmd.CooldownSeconds = parsed.Cooldown
+	}
+	if err := c.db.UpsertTextCommand(cmd); err != nil {
+		c.logger.Error("Failed to save text command", zap.String("command", cmd.Name), zap.Error(err))
+		return "", errors.New("could not save the command")
+	}
+	return fmt.Sprintf("Command !%s added.", cmd.Name), nil
+}
+
+func (c *ChatClient) editTextCommand(user string, args []string) (string, error) {
+	parsed, err := parseTextCommandArgs(args, c.roles)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Response == "" && parsed.Permission == "" && parsed.Cooldown < 0 {
+		return "", errors.New("usage: !editcom !name [-perm=role] [-cd=seconds] [response]")
+	}
+
+	cmd, err := c.db.GetTextCommand(parsed.Name)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("!%s does not exist", parsed.Name)
+	} else if err != nil {
+		return "", errors.New("could not load the command")
+	}
+
+	if parsed.Response != "" {
+		cmd.Response = parsed.Response
+	}
+	if parsed.Permission != "" {
+		cmd.Permission = parsed.Permission
+	}
+	if parsed.Cooldown >= 0 {
+		cmd.CooldownSeconds = parsed.Cooldown
+	}
+	cmd.UpdatedBy = user
+	cmd.UpdatedAt = time.Now().UTC()
+	if err := c.db.UpsertTextCommand(cmd); err != nil {
+		c.logger.Error("Failed to save text command", zap.String("command", cmd.Name), zap.Error(err))
+		return "", errors.New("could not save the command")
+	}
+	return fmt.Sprintf("Command !%s updated.", cmd.Name), nil
+}
+
+func (c *ChatClient) deleteTextCommand(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", errors.New("usage: !delcom !name")
+	}
+	name := strings.ToLower(strings.TrimPrefix(args[0], "!"))
+
+	err := c.db.DeleteTextCommand(name)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("!%s does not exist", name)
+	} else if err != nil {
+		c.logger.Error("Failed to delete text command", zap.String("command", name), zap.Error(err))
+		return "", errors.New("could not delete the command")
+	}
+	return fmt.Sprintf("Command !%s deleted.", name), nil
+}
+
+// textCommandResponse looks up a text command and returns its rendered response,
+// or an empty string if it does not exist, is not allowed for the user or is on cooldown.
+func (c *ChatClient) textCommandResponse(message twitch.PrivateMessage, name string, args []string) string {
+	if c.db == nil {
+		return ""
+	}
+	cmd, err := c.db.GetTextCommand(name)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			c.logger.Warn("Failed to load text command", zap.String("command", name), zap.Error(err))
+		}
+		return ""
+	}
+
+	if !c.hasPermission(message, cmd.Permission) {
+		return ""
+	}
+
+	if !c.checkCooldown(message, name, time.Duration(cmd.CooldownSeconds)*time.Second) {
+		return ""
+	}
+
+	c.logger.Info("Text command triggered", zap.String("command", name), zap.String("channel", message.Channel), zap.String("user", message.User.Name))
+	if c.templates == nil {
+		return cmd.Response
+	}
+	return c.templates.Render(cmd.Response, commands.Context{
+		Command: name,
+		User:    message.User.DisplayName,
+		Args:    args,
+		Channel: message.Channel,
+	})
+}
@@ -0,0 +1,90 @@
+package twitch
+
+import (
+	"testing"
+	"time"
+
+	"VLX_Robot/internal/commands"
+	"VLX_Robot/internal/database"
+
+	"github.com/gempir/go-twitch-irc/v4"
+	"go.uber.org/zap"
+)
+
+func TestParseTextCommandArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    textCommandArgs
+		wantErr bool
+	}{
+		{"Plain", []string{"!Discord", "join", "us"}, textCommandArgs{Name: "discord", Response: "join us", Cooldown: -1}, false},
+		{"Options", []string{"socials", "-perm=subscriber", "-cd=30", "links"}, textCommandArgs{Name: "socials", Response: "links", Permission: PermissionSubscriber, Cooldown: 30}, false},
+		{"OptionsOnly", []string{"!x", "-cd=0"}, textCommandArgs{Name: "x", Cooldown: 0}, false},
+		{"DashInResponse", []string{"!x", "-", "hi"}, textCommandArgs{Name: "x", Response: "- hi", Cooldown: -1}, false},
+		{"NoName", nil, textCommandArgs{}, true},
+		{"BadPermission", []string{"!x", "-perm=admin", "hi"}, textCommandArgs{}, true},
+		{"BadCooldown", []string{"!x", "-cd=-5", "hi"}, textCommandArgs{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTextCommandArgs(tt.args, commands.DefaultRoles())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTextCommandArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseTextCommandArgs() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextCommandLifecycle(t *testing.T) {
+	store := database.NewMemoryStore()
+	c := &ChatClient{
+		registry: commands.NewRegistry(AudioCommandsMap{"hello": {Filename: "everyone/hello.mp3", Permission: PermissionEveryone}},
+			commands.NewCooldowns(commands.CooldownPolicy{Command: 15 * time.Second})),
+		db:     store,
+		logger: zap.NewNop(),
+	}
+
+	mod := twitch.PrivateMessage{Channel: "streamer", User: twitch.User{Name: "mod", DisplayName: "Mod", Badges: map[string]int{"moderator": 1}}}
+	viewer := twitch.PrivateMessage{Channel: "streamer", User: twitch.User{Name: "viewer", DisplayName: "Viewer", Badges: map[string]int{}}}
+
+	if reply := c.manageTextCommand(viewer, CommandAdd, []string{"!discord", "link"}); reply != "" {
+		t.Errorf("Viewers must not manage commands, got %q", reply)
+	}
+	if reply := c.manageTextCommand(mod, CommandAdd, []string{"!hello", "hi"}); reply != "@Mod !hello is already a media command" {
+		t.Errorf("Unexpected reply for media clash: %q", reply)
+	}
+	if reply := c.manageTextCommand(mod, CommandAdd, []string{"!discord", "-cd=60", "discord.gg/x"}); reply != "Command !discord added." {
+		t.Errorf("Unexpected add reply: %q", reply)
+	}
+
+	if got := c.textCommandResponse(viewer, "discord", nil); got != "discord.gg/x" {
+		t.Errorf("Expected response, got %q", got)
+	}
+	if got := c.textCommandResponse(viewer, "discord", nil); got != "" {
+		t.Errorf("Expected command on cooldown, got %q", got)
+	}
+
+	if reply := c.manageTextCommand(mod, CommandEdit, []string{"!discord", "-perm=subscriber"}); reply != "Command !discord updated." {
+		t.Errorf("Unexpected edit reply: %q", reply)
+	}
+	cmd, err := store.GetTextCommand("discord")
+	if err != nil || cmd.Permission != PermissionSubscriber || cmd.Response != "discord.gg/x" || cmd.CooldownSeconds != 60 || cmd.UpdatedBy != "mod" {
+		t.Errorf("Unexpected stored command %+v (err: %v)", cmd, err)
+	}
+	c.registry = commands.NewRegistry(nil, nil)
+	if got := c.textCommandResponse(viewer, "discord", nil); got != "" {
+		t.Errorf("Subscriber-only command answered a viewer: %q", got)
+	}
+
+	if reply := c.manageTextCommand(mod, CommandDelete, []string{"!discord"}); reply != "Command !discord deleted." {
+		t.Errorf("Unexpected delete reply: %q", reply)
+	}
+	if reply := c.manageTextCommand(mod, CommandDelete, []string{"!discord"}); reply != "@Mod !discord does not exist" {
+		t.Errorf("Unexpected reply for missing command: %q", reply)
+	}
+}
@@ -0,0 +1,251 @@
+package twitch
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"VLX_Robot/internal/events"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// tokenCheckInterval is how often the token manager wakes up.
+	tokenCheckInterval = time.Minute
+	// tokenValidateInterval is the validation cadence Twitch requires for long-running apps.
+	tokenValidateInterval = time.Hour
+	// tokenRefreshMargin refreshes tokens this long before they expire.
+	tokenRefreshMargin = 10 * time.Minute
+	// minTokenBackoff and maxTokenBackoff bound the delay between failed refresh attempts.
+	minTokenBackoff = 30 * time.Second
+	maxTokenBackoff = 30 * time.Minute
+)
+
+// tokenState tracks one credential for the token manager.
+type tokenState struct {
+	expiresAt   time.Time
+	valid       bool
+	lastError   string
+	nextAttempt time.Time
+	backoff     time.Duration
+}
+
+// refreshed records a successful refresh or validation.
+func (s *tokenState) refreshed(expiresAt time.Time) {
+	s.expiresAt = expiresAt
+	s.valid = true
+	s.lastError = ""
+	s.backoff = 0
+	s.nextAttempt = time.Time{}
+}
+
+// failed records an error and schedules the next attempt with exponential backoff.
+// The token stays usable until it actually expires.
+func (s *tokenState) failed(now time.Time, err error) {
+	s.backoff = min(max(s.backoff*2, minTokenBackoff), maxTokenBackoff)
+	s.nextAttempt = now.Add(s.backoff)
+	s.lastError = err.Error()
+	s.valid = now.Before(s.expiresAt)
+}
+
+// due reports whether the token must be refreshed and the backoff allows another attempt.
+func (s *tokenState) due(now time.Time) bool {
+	return now.Add(tokenRefreshMargin).After(s.expiresAt) && !now.Before(s.nextAttempt)
+}
+
+// StartTokenManager refreshes the user and app tokens before they expire and validates them hourly.
+func (c *Client) StartTokenManager() {
+	go func() {
+		ticker := time.NewTicker(tokenCheckInterval)
+		defer ticker.Stop()
+
+		c.checkTokens(time.Now().UTC())
+		for now := range ticker.C {
+			c.checkTokens(now.UTC())
+		}
+	}()
+}
+
+// TokenHealth returns a snapshot of the credential state.
+func (c *Client) TokenHealth() events.TokenHealthData {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	return c.tokenHealthLocked()
+}
+
+// checkTokens runs one manager cycle and publishes the health status when it changes.
+// The DB reads and Twitch calls work on a snapshot, so TokenHealth and trackUserTokens never wait on the network.
+func (c *Client) checkTokens(now time.Time) {
+	c.tokenMu.Lock()
+	validate := now.Sub(c.lastValidated) >= tokenValidateInterval
+	users := make(map[string]tokenState, len(c.userTokens))
+	for userID, st := range c.userTokens {
+		users[userID] = *st
+	}
+	app := c.appToken
+	c.tokenMu.Unlock()
+
+	for userID, st := range users {
+		c.checkUserToken(userID, &st, now, validate)
+		users[userID] = st
+	}
+	c.checkAppToken(&app, now, validate)
+
+	c.tokenMu.Lock()
+	for userID, st := range users {
+		if tracked, ok := c.userTokens[userID]; ok {
+			*tracked = st
+		}
+	}
+	c.appToken = app
+	if validate {
+		c.lastValidated = now
+	}
+	health := c.tokenHealthLocked()
+	changed := health.UserTokenValid != c.lastHealth.UserTokenValid ||
+		health.AppTokenValid != c.lastHealth.AppTokenValid ||
+		health.Error != c.lastHealth.Error
+	c.lastHealth = health
+	c.tokenMu.Unlock()
+
+	if changed && c.hub != nil {
+		c.hub.Publish(events.New(events.PlatformSystem, events.TypeTokenHealth, "", nil, health))
+	}
+}
+
+// trackUserTokens adds broadcasters whose user tokens the manager keeps fresh.
+func (c *Client) trackUserTokens(userIDs ...string) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	for _, id := range userIDs {
+		if _, ok := c.userTokens[id]; id != "" && !ok {
+			c.userTokens[id] = &tokenState{}
+		}
+	}
+}
+
+// checkUserToken refreshes a broadcaster's user token from the DB, or validates the config token of the primary channel.
+func (c *Client) checkUserToken(userID string, st *tokenState, now time.Time, validate bool) {
+	creds, err := c.db.GetTwitchCredentials(userID)
+	if err == sql.ErrNoRows {
+		// Config token only: it cannot be refreshed, so just report whether it still works
+		if validate && userID == c.userID && c.config.UserAccessToken != "" {
+			c.validateToken(st, now, c.config.UserAccessToken, "user")
+		}
+		return
+	}
+	if err != nil {
+		st.failed(now, fmt.Errorf("load credentials: %w", err))
+		return
+	}
+	st.expiresAt = creds.ExpiresAt
+
+	if validate && !st.due(now) {
+		c.validateToken(st, now, creds.AccessToken, "user")
+		if !st.valid {
+			st.expiresAt = time.Time{} // Rejected by Twitch: force a refresh below
+		}
+	}
+	if !st.due(now) {
+		return
+	}
+
+	newCreds, err := c.refreshToken(creds)
+	if err != nil {
+		st.failed(now, err)
+		c.logger.Error("User token refresh failed", zap.String("user_id", userID), zap.Duration("retry_in", st.backoff), zap.Error(err))
+		return
+	}
+	st.refreshed(newCreds.ExpiresAt)
+	c.logger.Info("User token refreshed", zap.String("user_id", userID), zap.Time("expires_at", newCreds.ExpiresAt))
+}
+
+// checkAppToken renews the app access token used for webhooks and public Helix calls.
+func (c *Client) checkAppToken(st *tokenState, now time.Time, validate bool) {
+	if validate && !st.due(now) {
+		c.validateToken(st, now, c.helix.GetAppAccessToken(), "app")
+		if !st.valid {
+			st.expiresAt = time.Time{}
+		}
+	}
+	if !st.due(now) {
+		return
+	}
+
+	token, err := c.helix.RequestAppAccessToken(nil)
+	if err == nil && token.StatusCode >= 400 {
+		err = fmt.Errorf("api token error %d: %s", token.StatusCode, token.ErrorMessage)
+	}
+	if err != nil {
+		st.failed(now, err)
+		c.logger.Error("App token renewal failed", zap.Duration("retry_in", st.backoff), zap.Error(err))
+		return
+	}
+
+	c.helix.SetAppAccessToken(token.Data.AccessToken)
+	st.refreshed(now.Add(time.Second * time.Duration(token.Data.ExpiresIn)))
+	c.logger.Info("App access token renewed", zap.Time("expires_at", st.expiresAt))
+}
+
+// validateToken calls the Twitch validation endpoint and updates the state.
+// Network errors keep the previous validity; a rejected token is marked invalid.
+func (c *Client) validateToken(st *tokenState, now time.Time, token, kind string) {
+	valid, resp, err := c.helix.ValidateToken(token)
+	if err != nil {
+		c.logger.Warn("Token validation failed", zap.String("token", kind), zap.Error(err))
+		st.lastError = err.Error()
+		return
+	}
+	if !valid {
+		c.logger.Warn("Token rejected by Twitch", zap.String("token", kind))
+		st.valid = false
+		st.lastError = "token rejected by Twitch"
+		return
+	}
+	st.valid = true
+	st.lastError = ""
+	if resp != nil && resp.Data.ExpiresIn > 0 {
+		st.expiresAt = now.Add(time.Second * time.Duration(resp.Data.ExpiresIn))
+	}
+}
+
+// tokenHealthLocked builds the health snapshot. User fields aggregate every tracked broadcaster:
+// valid only if all tokens are, expiring at the earliest expiry. c.tokenMu must be held.
+func (c *Client) tokenHealthLocked() events.TokenHealthData {
+	health := events.TokenHealthData{
+		UserTokenValid: len(c.userTokens) > 0,
+		AppTokenValid:  c.appToken.valid,
+	}
+
+	ids := make([]string, 0, len(c.userTokens))
+	for id := range c.userTokens {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		st := c.userTokens[id]
+		health.UserTokenValid = health.UserTokenValid && st.valid
+		if health.Error == "" && st.lastError != "" {
+			health.Error = st.lastError
+		}
+		if !st.expiresAt.IsZero() && (health.UserExpiresAt == nil || st.expiresAt.Before(*health.UserExpiresAt)) {
+			t := st.expiresAt
+			health.UserExpiresAt = &t
+		}
+	}
+	if health.Error == "" {
+		health.Error = c.appToken.lastError
+	}
+	if !c.appToken.expiresAt.IsZero() {
+		t := c.appToken.expiresAt
+		health.AppExpiresAt = &t
+	}
+	if !c.lastValidated.IsZero() {
+		t := c.lastValidated
+		health.LastValidated = &t
+	}
+	return health
+}
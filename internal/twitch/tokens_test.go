@@ -0,0 +1,122 @@
+package twitch
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"VLX_Robot/internal/database"
+
+	"go.uber.org/zap"
+)
+
+func TestTokenStateBackoff(t *testing.T) {
+	now := time.Now().UTC()
+	st := tokenState{expiresAt: now.Add(5 * time.Minute)}
+
+	if !st.due(now) {
+		t.Fatal("Expected token inside the refresh margin to be due")
+	}
+
+	st.failed(now, errors.New("boom"))
+	if st.backoff != minTokenBackoff || !st.valid || st.lastError != "boom" {
+		t.Errorf("Unexpected state after first failure: %+v", st)
+	}
+	if st.due(now.Add(minTokenBackoff / 2)) {
+		t.Error("Expected retry to wait for the backoff")
+	}
+
+	for i := 0; i < 10; i++ {
+		st.failed(now, errors.New("boom"))
+	}
+	if st.backoff != maxTokenBackoff {
+		t.Errorf("Expected backoff capped at %v, got %v", maxTokenBackoff, st.backoff)
+	}
+
+	st.failed(now.Add(time.Hour), errors.New("boom"))
+	if st.valid {
+		t.Error("Expected expired token to be reported invalid")
+	}
+
+	st.refreshed(now.Add(4 * time.Hour))
+	if !st.valid || st.backoff != 0 || st.lastError != "" || st.due(now) {
+		t.Errorf("Unexpected state after refresh: %+v", st)
+	}
+}
+
+func TestCheckUserToken(t *testing.T) {
+	now := time.Now().UTC()
+	store := database.NewMemoryStore()
+	store.UpsertTwitchCredentials(&database.TwitchCredentials{UserID: "123", AccessToken: "a", RefreshToken: "r", ExpiresAt: now.Add(time.Hour)})
+
+	// helix is nil: a fresh token must not trigger any API call
+	c := &Client{db: store, userID: "123", userTokens: make(map[string]*tokenState), logger: zap.NewNop()}
+	c.trackUserTokens("123")
+	st := c.userTokens["123"]
+	c.checkUserToken("123", st, now, false)
+	if !st.expiresAt.Equal(now.Add(time.Hour)) || st.lastError != "" {
+		t.Errorf("Unexpected state for fresh token: %+v", st)
+	}
+
+	// Expired token without refresh token: refresh fails before reaching Helix and backs off
+	store.UpsertTwitchCredentials(&database.TwitchCredentials{UserID: "123", AccessToken: "a", ExpiresAt: now.Add(-time.Minute)})
+	c.checkUserToken("123", st, now, false)
+	if st.valid || st.backoff != minTokenBackoff {
+		t.Errorf("Expected failed refresh with backoff, got %+v", st)
+	}
+	health := c.TokenHealth()
+	if health.UserTokenValid || health.Error == "" {
+		t.Errorf("Expected unhealthy user token, got %+v", health)
+	}
+}
+
+// slowCredentialStore stalls credential reads until released.
+type slowCredentialStore struct {
+	*database.MemoryStore
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (s *slowCredentialStore) GetTwitchCredentials(userID string) (*database.TwitchCredentials, error) {
+	s.entered <- struct{}{}
+	<-s.release
+	return s.MemoryStore.GetTwitchCredentials(userID)
+}
+
+func TestCheckTokensDoesNotBlockHealth(t *testing.T) {
+	now := time.Now().UTC()
+	store := &slowCredentialStore{MemoryStore: database.NewMemoryStore(), entered: make(chan struct{}), release: make(chan struct{})}
+	store.UpsertTwitchCredentials(&database.TwitchCredentials{UserID: "123", AccessToken: "a", RefreshToken: "r", ExpiresAt: now.Add(time.Hour)})
+
+	// App token fresh and recently validated: helix (nil) is never called
+	c := &Client{db: store, userID: "123", userTokens: make(map[string]*tokenState), lastValidated: now, logger: zap.NewNop()}
+	c.appToken.refreshed(now.Add(time.Hour))
+	c.trackUserTokens("123")
+
+	done := make(chan struct{})
+	go func() {
+		c.checkTokens(now)
+		close(done)
+	}()
+	<-store.entered
+
+	// The cycle is stuck on the DB; health and tracking must still respond
+	result := make(chan struct{})
+	go func() {
+		c.TokenHealth()
+		c.trackUserTokens("456")
+		close(result)
+	}()
+	select {
+	case <-result:
+	case <-time.After(time.Second):
+		t.Fatal("TokenHealth blocked by the token check")
+	}
+
+	// "456" was tracked after the snapshot, so this cycle reads no other credentials
+	close(store.release)
+	<-done
+	if st := c.TokenHealth(); st.UserExpiresAt == nil || !st.UserExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("Expected the cycle result to be written back, got %+v", st)
+	}
+}
@@ -0,0 +1,36 @@
+package twitch
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/nicklaw5/helix/v2"
+)
+
+// EnableWhispers requests the whisper scope during authorization so the bot can whisper cooldown notices.
+func (c *Client) EnableWhispers() {
+	c.whispers = true
+}
+
+// Whisper sends a private message from the primary broadcaster account via Helix.
+// Twitch requires the user:manage:whispers scope and a verified phone number on the sender.
+func (c *Client) Whisper(toUserID, message string) error {
+	if c.userID == "" {
+		return errors.New("primary channel user ID is unknown")
+	}
+	return c.withUserToken(c.userID, func(api *helix.Client) error {
+		resp, err := api.SendUserWhisper(&helix.SendUserWhisperParams{
+			FromUserID: c.userID,
+			ToUserID:   toUserID,
+			Message:    message,
+		})
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusNoContent {
+			return fmt.Errorf("api status %d: %s", resp.StatusCode, resp.ErrorMessage)
+		}
+		return nil
+	})
+}
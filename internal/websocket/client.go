@@ -1,9 +1,15 @@
 package websocket
 
 import (
+	"encoding/json"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"VLX_Robot/internal/events"
+
 	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
 )
@@ -27,7 +33,99 @@ type Client struct {
 	hub    *Hub
 	conn   *websocket.Conn
 	send   chan []byte
-	logger *zap.Logger
+	topics map[string]bool // nil means "all topics", empty means none; only mutated by the Hub loop
+	// resumeFrom is the last sequence number the overlay saw before reconnecting (0 = no replay).
+	resumeFrom int64
+	logger     *zap.Logger
+}
+
+// clientFrame is a control message sent by overlays, e.g. {"action":"subscribe","topics":["alerts"]}
+// or {"action":"resume","last_seq":42}.
+type clientFrame struct {
+	Action  string   `json:"action"`
+	Topics  []string `json:"topics"`
+	LastSeq int64    `json:"last_seq"`
+}
+
+// wants reports whether the client is subscribed to the topic.
+func (c *Client) wants(topic string) bool {
+	if c.topics == nil || topic == "" {
+		return true
+	}
+	return c.topics[topic]
+}
+
+// updateTopics applies a subscribe or unsubscribe frame. Subscribing narrows an all-topics client to the
+// listed topics; unsubscribing removes topics, so dropping the last one leaves the client with none.
+func (c *Client) updateTopics(topics []string, unsubscribe bool) {
+	if c.topics == nil {
+		c.topics = make(map[string]bool)
+		if unsubscribe {
+			for _, topic := range events.Topics {
+				c.topics[topic] = true
+			}
+		}
+	}
+	for _, topic := range topics {
+		if unsubscribe {
+			delete(c.topics, topic)
+		} else {
+			c.topics[topic] = true
+		}
+	}
+}
+
+// topicList returns the subscribed topics in sorted order (for logging).
+func (c *Client) topicList() []string {
+	if c.topics == nil {
+		return []string{"*"}
+	}
+	list := make([]string, 0, len(c.topics))
+	for topic := range c.topics {
+		list = append(list, topic)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// parseTopics splits a comma-separated topic list, ignoring blanks. No topics means all of them (nil).
+func parseTopics(raw string) map[string]bool {
+	var topics map[string]bool
+	for _, topic := range strings.Split(raw, ",") {
+		topic = strings.ToLower(strings.TrimSpace(topic))
+		if topic == "" {
+			continue
+		}
+		if topics == nil {
+			topics = make(map[string]bool)
+		}
+		topics[topic] = true
+	}
+	return topics
+}
+
+// handleFrame applies a control frame received from the overlay.
+func (c *Client) handleFrame(raw []byte) {
+	var frame clientFrame
+	if err := json.Unmarshal(raw, &frame); err != nil {
+		c.logger.Debug("Ignoring non-JSON WebSocket frame", zap.Error(err))
+		return
+	}
+
+	switch frame.Action {
+	case "subscribe", "unsubscribe":
+		topics := make([]string, 0, len(frame.Topics))
+		for _, topic := range frame.Topics {
+			if topic = strings.ToLower(strings.TrimSpace(topic)); topic != "" {
+				topics = append(topics, topic)
+			}
+		}
+		c.hub.subscribe <- subscription{client: c, topics: topics, unsubscribe: frame.Action == "unsubscribe"}
+	case "resume":
+		c.hub.replay <- replayRequest{client: c, lastSeq: frame.LastSeq}
+	default:
+		c.logger.Debug("Unknown WebSocket frame action", zap.String("action", frame.Action))
+	}
 }
 
 func (c *Client) readPump() {
@@ -43,13 +141,14 @@ func (c *Client) readPump() {
 	})
 
 	for {
-		_, _, err := c.conn.ReadMessage()
+		_, message, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				c.logger.Error("WebSocket unexpected close error", zap.Error(err))
 			}
 			break
 		}
+		c.handleFrame(message)
 	}
 }
 
@@ -69,18 +168,8 @@ func (c *Client) writePump() {
 				return
 			}
 
-			w, err := c.conn.NextWriter(websocket.TextMessage)
-			if err != nil {
-				return
-			}
-			w.Write(message)
-
-			n := len(c.send)
-			for i := 0; i < n; i++ {
-				w.Write(<-c.send)
-			}
-
-			if err := w.Close(); err != nil {
+			// One frame per event: overlays parse each frame as a single JSON envelope
+			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
 				return
 			}
 
@@ -94,6 +183,8 @@ func (c *Client) writePump() {
 }
 
 // ServeWs handles WebSocket requests from clients.
+// Clients may pre-select topics with the "topics" query parameter (e.g. ?topics=alerts,media)
+// and request missed events with "last_seq".
 func ServeWs(hub *Hub, logger *zap.Logger, w http.ResponseWriter, r *http.Request) {
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -101,11 +192,16 @@ func ServeWs(hub *Hub, logger *zap.Logger, w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	query := r.URL.Query()
+	lastSeq, _ := strconv.ParseInt(query.Get("last_seq"), 10, 64)
+
 	client := &Client{
-		hub:    hub,
-		conn:   conn,
-		send:   make(chan []byte, 256),
-		logger: logger,
+		hub:        hub,
+		conn:       conn,
+		send:       make(chan []byte, 256),
+		topics:     parseTopics(query.Get("topics")),
+		resumeFrom: lastSeq,
+		logger:     logger,
 	}
 
 	client.hub.register <- client
@@ -0,0 +1,57 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"VLX_Robot/internal/database"
+	"VLX_Robot/internal/events"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+func TestServeWsReplayOneFramePerEvent(t *testing.T) {
+	logger := zap.NewNop()
+	hub := NewHub(logger)
+	if err := hub.EnableHistory(database.NewMemoryStore(), time.Hour); err != nil {
+		t.Fatalf("EnableHistory failed: %v", err)
+	}
+	go hub.Run()
+
+	for _, id := range []string{"one", "two", "three", "four"} {
+		hub.Publish(events.New(events.PlatformTwitch, events.TypeTwitchFollow, id, nil, nil))
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ServeWs(hub, logger, w, r)
+	}))
+	defer server.Close()
+
+	// The replay queues three events at once; each must arrive as its own frame
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws?last_seq=1", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for _, want := range []string{"two", "three", "four"} {
+		_, frame, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("ReadMessage failed: %v", err)
+		}
+		var evt events.Event
+		if err := json.Unmarshal(frame, &evt); err != nil {
+			t.Fatalf("Frame is not a single JSON envelope: %v (%q)", err, frame)
+		}
+		if evt.ID != want {
+			t.Errorf("Expected %s, got %s", want, evt.ID)
+		}
+	}
+}
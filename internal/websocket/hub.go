@@ -1,32 +1,108 @@
 package websocket
 
-import "go.uber.org/zap"
+import (
+	"time"
+
+	"VLX_Robot/internal/database"
+	"VLX_Robot/internal/events"
+
+	"go.uber.org/zap"
+)
+
+// maxReplayEvents caps how many missed events a reconnecting client receives.
+const maxReplayEvents = 200
+
+// pruneInterval is how often expired history entries are deleted.
+const pruneInterval = time.Minute
+
+// broadcastBuffer lets producers publish without waiting for the Hub loop.
+const broadcastBuffer = 256
+
+// historyQueueSize is how many events may wait for the history writer before new ones are dropped from history.
+const historyQueueSize = 1024
+
+// EventHistory persists broadcast events so reconnecting clients can catch up.
+type EventHistory interface {
+	AppendEvent(rec *database.EventRecord) error
+	GetEventsSince(seq int64, limit int) ([]database.EventRecord, error)
+	GetLastEventSeq() (int64, error)
+	PruneEvents(before time.Time) error
+}
+
+// subscription changes the topic set of a client from inside the Hub loop.
+type subscription struct {
+	client      *Client
+	topics      []string
+	unsubscribe bool
+}
+
+// replayRequest asks the Hub to resend events newer than lastSeq to a client.
+type replayRequest struct {
+	client  *Client
+	lastSeq int64
+}
 
 // Hub manages the set of active clients and broadcasts messages.
 type Hub struct {
 	clients    map[*Client]bool
-	Broadcast  chan []byte
+	Broadcast  chan *events.Event
 	register   chan *Client
 	unregister chan *Client
+	subscribe  chan subscription
+	replay     chan replayRequest
+	history    EventHistory
+	records    chan *database.EventRecord // Queue of the history writer
+	recent     []database.EventRecord     // Last maxReplayEvents events, so replays do not wait for the writer
+	retention  time.Duration
+	lastSeq    int64
 	logger     *zap.Logger
 }
 
 func NewHub(logger *zap.Logger) *Hub {
 	return &Hub{
-		Broadcast:  make(chan []byte),
+		Broadcast:  make(chan *events.Event, broadcastBuffer),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
+		subscribe:  make(chan subscription),
+		replay:     make(chan replayRequest),
 		clients:    make(map[*Client]bool),
 		logger:     logger,
 	}
 }
 
+// EnableHistory stores every broadcast with a sequence number and keeps it for the retention window.
+// It must be called before Run.
+func (h *Hub) EnableHistory(history EventHistory, retention time.Duration) error {
+	lastSeq, err := history.GetLastEventSeq()
+	if err != nil {
+		return err
+	}
+	h.history = history
+	h.records = make(chan *database.EventRecord, historyQueueSize)
+	h.retention = retention
+	h.lastSeq = lastSeq
+	h.logger.Info("Event history enabled", zap.Int64("last_seq", lastSeq), zap.Duration("retention", retention))
+	return nil
+}
+
+// Publish sends an event to every client interested in its topic.
+func (h *Hub) Publish(evt *events.Event) {
+	h.Broadcast <- evt
+}
+
 func (h *Hub) Run() {
+	if h.history != nil {
+		go h.writeHistory()
+	}
+
 	for {
 		select {
 		case client := <-h.register:
 			h.clients[client] = true
-			h.logger.Info("New WebSocket client registered")
+			h.logger.Info("New WebSocket client registered", zap.Strings("topics", client.topicList()))
+			if client.resumeFrom > 0 {
+				h.replayTo(client, client.resumeFrom)
+			}
 
 		case client := <-h.unregister:
 			if _, ok := h.clients[client]; ok {
@@ -35,16 +111,148 @@ func (h *Hub) Run() {
 				h.logger.Info("WebSocket client unregistered")
 			}
 
-		case message := <-h.Broadcast:
-			for client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					h.logger.Warn("Client buffer full, forcing unregister")
-					close(client.send)
-					delete(h.clients, client)
-				}
+		case sub := <-h.subscribe:
+			if _, ok := h.clients[sub.client]; !ok {
+				continue
+			}
+			sub.client.updateTopics(sub.topics, sub.unsubscribe)
+			h.logger.Info("WebSocket client topics updated", zap.Strings("topics", sub.client.topicList()))
+
+		case req := <-h.replay:
+			if _, ok := h.clients[req.client]; ok {
+				h.replayTo(req.client, req.lastSeq)
+			}
+
+		case evt := <-h.Broadcast:
+			h.dispatch(evt)
+		}
+	}
+}
+
+// dispatch stamps the event with the next sequence number, queues it for the history writer and fans it out.
+func (h *Hub) dispatch(evt *events.Event) {
+	h.lastSeq++
+	evt.Seq = h.lastSeq
+
+	data, err := evt.Marshal()
+	if err != nil {
+		h.logger.Error("Failed to encode event", zap.String("type", evt.Type), zap.Error(err))
+		return
+	}
+	topic := evt.Topic()
+
+	if h.history != nil {
+		rec := database.EventRecord{Seq: evt.Seq, Topic: topic, Payload: data, CreatedAt: evt.Timestamp}
+		h.recent = append(h.recent, rec)
+		if len(h.recent) > maxReplayEvents {
+			h.recent = h.recent[len(h.recent)-maxReplayEvents:]
+		}
+		select {
+		case h.records <- &rec:
+		default:
+			h.logger.Warn("Event history queue full, event will not be replayable", zap.Int64("seq", evt.Seq))
+		}
+	}
+
+	for client := range h.clients {
+		if client.wants(topic) {
+			h.send(client, data)
+		}
+	}
+}
+
+// replayTo resends the stored events the client missed, filtered by its topics.
+func (h *Hub) replayTo(client *Client, lastSeq int64) {
+	if h.history == nil || lastSeq >= h.lastSeq {
+		return
+	}
+
+	records, err := h.missedEvents(lastSeq)
+	if err != nil {
+		h.logger.Warn("Failed to load event history", zap.Int64("last_seq", lastSeq), zap.Error(err))
+		return
+	}
+
+	replayed := 0
+	for _, rec := range records {
+		if !client.wants(rec.Topic) {
+			continue
+		}
+		if !h.send(client, rec.Payload) {
+			return
+		}
+		replayed++
+	}
+	h.logger.Info("Replayed missed events", zap.Int64("last_seq", lastSeq), zap.Int("count", replayed))
+}
+
+// missedEvents returns the events after lastSeq. Recent events come from memory, since the writer may not
+// have stored them yet; older ones are loaded from the history.
+func (h *Hub) missedEvents(lastSeq int64) ([]database.EventRecord, error) {
+	if len(h.recent) > 0 && h.recent[0].Seq <= lastSeq+1 {
+		return h.recentSince(lastSeq), nil
+	}
+
+	records, err := h.history.GetEventsSince(lastSeq, maxReplayEvents)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == maxReplayEvents {
+		return records, nil
+	}
+	if len(records) > 0 {
+		lastSeq = records[len(records)-1].Seq
+	}
+	return append(records, h.recentSince(lastSeq)...), nil
+}
+
+// recentSince returns the in-memory events after seq.
+func (h *Hub) recentSince(seq int64) []database.EventRecord {
+	for i, rec := range h.recent {
+		if rec.Seq > seq {
+			return append([]database.EventRecord(nil), h.recent[i:]...)
+		}
+	}
+	return nil
+}
+
+// send queues data for the client, dropping the client if its buffer is full.
+func (h *Hub) send(client *Client, data []byte) bool {
+	select {
+	case client.send <- data:
+		return true
+	default:
+		h.logger.Warn("Client buffer full, forcing unregister")
+		close(client.send)
+		delete(h.clients, client)
+		return false
+	}
+}
+
+// writeHistory stores queued events and prunes expired ones, away from the Hub loop so a slow
+// database never delays broadcasts.
+func (h *Hub) writeHistory() {
+	pruneTicker := time.NewTicker(pruneInterval)
+	defer pruneTicker.Stop()
+
+	for {
+		select {
+		case rec := <-h.records:
+			if err := h.history.AppendEvent(rec); err != nil {
+				h.logger.Warn("Failed to store event history", zap.Int64("seq", rec.Seq), zap.Error(err))
 			}
+		case <-pruneTicker.C:
+			h.pruneHistory()
 		}
 	}
 }
+
+// pruneHistory deletes events older than the retention window.
+func (h *Hub) pruneHistory() {
+	if h.history == nil || h.retention <= 0 {
+		return
+	}
+	if err := h.history.PruneEvents(time.Now().UTC().Add(-h.retention)); err != nil {
+		h.logger.Warn("Failed to prune event history", zap.Error(err))
+	}
+}
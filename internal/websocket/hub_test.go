@@ -1,9 +1,14 @@
 package websocket
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
+	"VLX_Robot/internal/database"
+	"VLX_Robot/internal/events"
+
 	"go.uber.org/zap"
 )
 
@@ -21,37 +26,188 @@ func TestHubIntegration(t *testing.T) {
 		logger: logger,
 	}
 
-	// 2. Register Client
+	// 2. Register Client (the Hub owns its client set, so registration is verified by delivery below)
 	hub.register <- mockClient
-	
-	// Give a moment for registration
-	time.Sleep(50 * time.Millisecond)
-
-	// Check internal state (white-box testing)
-	if _, ok := hub.clients[mockClient]; !ok {
-		t.Fatal("Client was not registered in Hub")
-	}
 
 	// 3. Broadcast Message
-	testMsg := []byte("test_payload")
-	hub.Broadcast <- testMsg
+	hub.Publish(events.New(events.PlatformSystem, events.TypeTwitchFollow, "test-id", nil, nil))
 
 	// 4. Verify Receipt
 	select {
 	case received := <-mockClient.send:
-		if string(received) != string(testMsg) {
-			t.Errorf("Expected %s, got %s", testMsg, received)
+		var evt events.Event
+		if err := json.Unmarshal(received, &evt); err != nil {
+			t.Fatalf("Failed to unmarshal JSON: %v", err)
+		}
+		if evt.ID != "test-id" || evt.Seq != 1 {
+			t.Errorf("Expected test-id with seq 1, got %s with seq %d", evt.ID, evt.Seq)
 		}
 	case <-time.After(1 * time.Second):
 		t.Fatal("Timeout: Client did not receive broadcast")
 	}
 
-	// 5. Unregister Client
+	// 5. Unregister Client: the Hub closes the send channel when it drops the client
 	hub.unregister <- mockClient
-	time.Sleep(50 * time.Millisecond)
 
-	if _, ok := hub.clients[mockClient]; ok {
+	select {
+	case _, open := <-mockClient.send:
+		if open {
+			t.Fatal("Unexpected message after unregister")
+		}
+	case <-time.After(1 * time.Second):
 		t.Fatal("Client was not removed from Hub")
 	}
 }
 
+func TestHubTopicRouting(t *testing.T) {
+	logger := zap.NewNop()
+	hub := NewHub(logger)
+	go hub.Run()
+
+	alerts := &Client{hub: hub, send: make(chan []byte, 256), topics: parseTopics("alerts"), logger: logger}
+	emotes := &Client{hub: hub, send: make(chan []byte, 256), topics: parseTopics(" Emotes ,"), logger: logger}
+	everything := &Client{hub: hub, send: make(chan []byte, 256), topics: parseTopics(""), logger: logger}
+	hub.register <- alerts
+	hub.register <- emotes
+	hub.register <- everything
+
+	hub.Publish(events.New(events.PlatformTwitch, events.TypeTwitchFollow, "alert", nil, nil))
+	hub.Publish(events.New(events.PlatformTwitch, events.TypeEmoteWall, "emote", nil, nil))
+
+	// Subscribe frame adds a topic at runtime
+	hub.subscribe <- subscription{client: emotes, topics: []string{"alerts"}}
+	hub.Publish(events.New(events.PlatformTwitch, events.TypeTwitchRaid, "alert2", nil, nil))
+	time.Sleep(50 * time.Millisecond)
+
+	expect := map[*Client][]string{
+		alerts:     {"alert", "alert2"},
+		emotes:     {"emote", "alert2"},
+		everything: {"alert", "emote", "alert2"},
+	}
+	for client, want := range expect {
+		if got := drainIDs(t, client); strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestHubUnsubscribe(t *testing.T) {
+	logger := zap.NewNop()
+	hub := NewHub(logger)
+	go hub.Run()
+
+	alerts := &Client{hub: hub, send: make(chan []byte, 256), topics: parseTopics("alerts"), logger: logger}
+	everything := &Client{hub: hub, send: make(chan []byte, 256), topics: parseTopics(""), logger: logger}
+	hub.register <- alerts
+	hub.register <- everything
+
+	// Dropping the last topic means no topics, not all of them
+	hub.subscribe <- subscription{client: alerts, topics: []string{"alerts"}, unsubscribe: true}
+	// An all-topics client keeps every other topic
+	hub.subscribe <- subscription{client: everything, topics: []string{"emotes"}, unsubscribe: true}
+
+	hub.Publish(events.New(events.PlatformTwitch, events.TypeTwitchFollow, "alert", nil, nil))
+	hub.Publish(events.New(events.PlatformTwitch, events.TypeEmoteWall, "emote", nil, nil))
+	time.Sleep(50 * time.Millisecond)
+
+	if got := drainIDs(t, alerts); len(got) != 0 {
+		t.Errorf("Expected nothing after unsubscribing the last topic, got %v", got)
+	}
+	if got := drainIDs(t, everything); strings.Join(got, ",") != "alert" {
+		t.Errorf("Expected [alert], got %v", got)
+	}
+}
+
+func TestHubReplay(t *testing.T) {
+	logger := zap.NewNop()
+	hub := NewHub(logger)
+	if err := hub.EnableHistory(database.NewMemoryStore(), time.Hour); err != nil {
+		t.Fatalf("EnableHistory failed: %v", err)
+	}
+	go hub.Run()
+
+	hub.Publish(events.New(events.PlatformTwitch, events.TypeTwitchFollow, "one", nil, nil))
+	hub.Publish(events.New(events.PlatformTwitch, events.TypeEmoteWall, "two", nil, nil))
+	hub.Publish(events.New(events.PlatformTwitch, events.TypeTwitchCheer, "three", nil, nil))
+	time.Sleep(50 * time.Millisecond) // Broadcast is buffered; let the Hub stamp them first
+
+	// Overlay saw seq 1, reconnects subscribed to alerts only
+	client := &Client{hub: hub, send: make(chan []byte, 256), topics: parseTopics("alerts"), resumeFrom: 1, logger: logger}
+	hub.register <- client
+	time.Sleep(50 * time.Millisecond)
+
+	if got := drainIDs(t, client); strings.Join(got, ",") != "three" {
+		t.Errorf("Expected replay of [three], got %v", got)
+	}
+
+	// Resume frame replays again from an earlier point
+	hub.replay <- replayRequest{client: client, lastSeq: 0}
+	time.Sleep(50 * time.Millisecond)
+
+	if got := drainIDs(t, client); strings.Join(got, ",") != "one,three" {
+		t.Errorf("Expected replay of [one three], got %v", got)
+	}
+}
+
+// blockingHistory stalls AppendEvent until released, like an unreachable database.
+type blockingHistory struct {
+	*database.MemoryStore
+	release chan struct{}
+}
+
+func (b *blockingHistory) AppendEvent(rec *database.EventRecord) error {
+	<-b.release
+	return b.MemoryStore.AppendEvent(rec)
+}
+
+func TestHubSlowHistory(t *testing.T) {
+	logger := zap.NewNop()
+	hub := NewHub(logger)
+	history := &blockingHistory{MemoryStore: database.NewMemoryStore(), release: make(chan struct{})}
+	if err := hub.EnableHistory(history, time.Hour); err != nil {
+		t.Fatalf("EnableHistory failed: %v", err)
+	}
+	go hub.Run()
+
+	client := &Client{hub: hub, send: make(chan []byte, 256), logger: logger}
+	hub.register <- client
+
+	// Broadcasts keep flowing while the database is stuck
+	hub.Publish(events.New(events.PlatformTwitch, events.TypeTwitchFollow, "one", nil, nil))
+	hub.Publish(events.New(events.PlatformTwitch, events.TypeTwitchCheer, "two", nil, nil))
+	for _, want := range []string{"one", "two"} {
+		select {
+		case received := <-client.send:
+			var evt events.Event
+			if err := json.Unmarshal(received, &evt); err != nil || evt.ID != want {
+				t.Fatalf("Expected %s, got %s (%v)", want, evt.ID, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Broadcast blocked by the history writer")
+		}
+	}
+
+	// Unstored events are still replayed from memory
+	late := &Client{hub: hub, send: make(chan []byte, 256), resumeFrom: 1, logger: logger}
+	hub.register <- late
+	time.Sleep(50 * time.Millisecond)
+	if got := drainIDs(t, late); strings.Join(got, ",") != "two" {
+		t.Errorf("Expected replay of [two], got %v", got)
+	}
+
+	close(history.release)
+}
+
+// drainIDs returns the IDs of all events queued for the client.
+func drainIDs(t *testing.T, client *Client) []string {
+	t.Helper()
+	var ids []string
+	for len(client.send) > 0 {
+		var evt events.Event
+		if err := json.Unmarshal(<-client.send, &evt); err != nil {
+			t.Fatalf("Failed to unmarshal JSON: %v", err)
+		}
+		ids = append(ids, evt.ID)
+	}
+	return ids
+}
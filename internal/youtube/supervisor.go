@@ -0,0 +1,232 @@
+package youtube
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/api/googleapi"
+)
+
+// Supervisor states
+const (
+	StateSearching = "searching" // Looking up the active broadcast on a back-off schedule
+	StatePolling   = "polling"   // Reading the live chat
+	StateEnded     = "ended"     // The broadcast is over; waiting for the next lookup or a go-live signal
+)
+
+const (
+	// minDiscoveryBackoff is the delay after the first missed live stream lookup or quota error. It doubles after each one.
+	minDiscoveryBackoff = time.Minute
+	// maxDiscoveryBackoff caps the delay; Search.List costs 100 quota units per call.
+	maxDiscoveryBackoff = 30 * time.Minute
+)
+
+// errChatOffline is returned by pollChat once the response carries offlineAt.
+var errChatOffline = errors.New("live chat went offline")
+
+// chatEndedReasons are the LiveChatMessages.List error reasons meaning the broadcast's chat is gone.
+var chatEndedReasons = map[string]bool{
+	"liveChatEnded":    true,
+	"liveChatNotFound": true,
+	"liveChatDisabled": true,
+}
+
+// quotaReasons are the error reasons meaning the API key is out of quota; polling backs off instead of retrying.
+var quotaReasons = map[string]bool{
+	"quotaExceeded":         true,
+	"dailyLimitExceeded":    true,
+	"rateLimitExceeded":     true,
+	"userRateLimitExceeded": true,
+}
+
+// SetStreamLive pauses polling when the stream goes offline and re-discovers the live chat when it comes back online.
+func (c *Client) SetStreamLive(live bool) {
+	if c == nil {
+		return
+	}
+	c.streamStatus <- live
+}
+
+// boot enters the initial state: searching right away, or ended until go-live when the stream was offline at startup.
+func (c *Client) boot() {
+	if c.startOffline {
+		c.setState(StateEnded)
+		c.logger.Info("Stream offline at startup, YouTube polling waits for go-live")
+		return
+	}
+	c.setState(StateSearching)
+	c.discover()
+}
+
+// run drives the supervisor until the process exits. While polling, each tick waits as long as the server asked.
+func (c *Client) run() {
+	timer := time.NewTimer(c.pollingInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case live := <-c.streamStatus:
+			c.applyStreamStatus(live)
+		case <-timer.C:
+			c.tick()
+			timer.Reset(c.nextPoll)
+		}
+	}
+}
+
+// tick polls the chat, or runs the scheduled live stream lookup.
+func (c *Client) tick() {
+	c.nextPoll = c.pollingInterval
+
+	switch c.state {
+	case StatePolling:
+		err := c.pollChat()
+		if err == nil {
+			c.backoff = 0
+			return
+		}
+		if isChatEnded(err) || errors.Is(err, errChatOffline) {
+			c.logger.Info("YouTube live chat ended", zap.Error(err))
+			c.endBroadcast()
+			c.scheduleDiscovery()
+			return
+		}
+		if hasReason(err, quotaReasons) {
+			c.growBackoff()
+			c.nextPoll = c.backoff
+			c.logger.Warn("YouTube quota exceeded, backing off", zap.Duration("retry_in", c.backoff), zap.Error(err))
+			return
+		}
+		c.logger.Error("YouTube polling cycle failed", zap.Error(err))
+
+	case StateSearching, StateEnded:
+		if !c.nextDiscovery.IsZero() && !time.Now().Before(c.nextDiscovery) {
+			c.setState(StateSearching)
+			c.discover()
+		}
+	}
+}
+
+// discover looks up the active live chat and starts polling on success, or schedules the next lookup.
+func (c *Client) discover() {
+	lookup := c.ensureLiveChatID
+	if c.findLiveChat != nil {
+		lookup = c.findLiveChat
+	}
+
+	if err := lookup(); err != nil {
+		c.scheduleDiscovery()
+		c.logger.Warn("YouTube live chat not found, retrying later", zap.Duration("retry_in", c.backoff), zap.Error(err))
+		return
+	}
+
+	c.backoff = 0
+	c.nextDiscovery = time.Time{}
+	c.setState(StatePolling)
+	c.logger.Info("YouTube Live Chat ID initialized. Starting Polling Engine.")
+}
+
+// scheduleDiscovery plans the next lookup, doubling the delay after each miss up to maxDiscoveryBackoff.
+func (c *Client) scheduleDiscovery() {
+	c.growBackoff()
+	c.nextDiscovery = time.Now().Add(c.backoff)
+}
+
+// growBackoff doubles the back-off, starting at minDiscoveryBackoff and capped at maxDiscoveryBackoff.
+func (c *Client) growBackoff() {
+	switch {
+	case c.backoff < minDiscoveryBackoff:
+		c.backoff = minDiscoveryBackoff
+	case c.backoff < maxDiscoveryBackoff:
+		c.backoff *= 2
+		if c.backoff > maxDiscoveryBackoff {
+			c.backoff = maxDiscoveryBackoff
+		}
+	}
+}
+
+// serverInterval turns the pollingIntervalMillis hint into the next polling delay, clamped between
+// the configured polling_interval and max_polling_interval. Without a hint the configured interval is used.
+func (c *Client) serverInterval(millis int64) time.Duration {
+	if millis <= 0 {
+		return c.pollingInterval
+	}
+	interval := time.Duration(millis) * time.Millisecond
+	if interval < c.pollingInterval {
+		return c.pollingInterval
+	}
+	if interval > c.maxPolling {
+		return c.maxPolling
+	}
+	return interval
+}
+
+// endBroadcast stops polling and clears the page token so the next broadcast starts fresh.
+func (c *Client) endBroadcast() {
+	c.setState(StateEnded)
+	c.backoff = 0
+	c.nextDiscovery = time.Time{}
+	if err := c.resetPageToken(); err != nil {
+		c.logger.Warn("Failed to reset NextPageToken", zap.Error(err))
+	}
+}
+
+// applyStreamStatus reacts to a Twitch stream lifecycle transition.
+func (c *Client) applyStreamStatus(live bool) {
+	if live {
+		c.logger.Info("Stream online, discovering YouTube live chat")
+		c.backoff = 0
+		c.setState(StateSearching)
+		c.discover()
+		return
+	}
+
+	// No lookups until the next go-live signal
+	c.endBroadcast()
+	c.logger.Info("Stream offline, YouTube polling paused")
+}
+
+// setState records a supervisor transition.
+func (c *Client) setState(state string) {
+	if c.state == state {
+		return
+	}
+	c.logger.Info("YouTube supervisor state changed", zap.String("from", c.state), zap.String("to", state))
+	c.state = state
+}
+
+// resetPageToken clears the stored NextPageToken so the next broadcast starts from a fresh page.
+func (c *Client) resetPageToken() error {
+	state, err := c.db.GetYouTubeState(c.channelID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	state.NextPageToken = sql.NullString{}
+	state.UpdatedAt = time.Now()
+	return c.db.UpsertYouTubeState(state)
+}
+
+// isChatEnded reports whether a LiveChatMessages.List error means the chat is gone for good.
+func isChatEnded(err error) bool {
+	return hasReason(err, chatEndedReasons)
+}
+
+// hasReason reports whether err is a Google API error carrying one of the reasons.
+func hasReason(err error, reasons map[string]bool) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	for _, item := range apiErr.Errors {
+		if reasons[item.Reason] {
+			return true
+		}
+	}
+	return false
+}
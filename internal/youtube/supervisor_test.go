@@ -0,0 +1,264 @@
+package youtube
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"VLX_Robot/internal/database"
+
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/youtube/v3"
+)
+
+func TestSupervisorBackoff(t *testing.T) {
+	client := &Client{
+		logger:       zap.NewNop(),
+		findLiveChat: func() error { return errors.New("not live") },
+	}
+
+	// Not live at boot: keep searching with a growing delay
+	var delays []time.Duration
+	client.setState(StateSearching)
+	for i := 0; i < 7; i++ {
+		client.discover()
+		delays = append(delays, client.backoff)
+	}
+	want := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 16 * time.Minute, 30 * time.Minute, 30 * time.Minute}
+	for i := range want {
+		if delays[i] != want[i] {
+			t.Fatalf("Back-off = %v, want %v", delays, want)
+		}
+	}
+	if client.state != StateSearching || client.nextDiscovery.IsZero() {
+		t.Errorf("Expected a scheduled lookup, got state=%s", client.state)
+	}
+}
+
+func TestSupervisorChatEnded(t *testing.T) {
+	store := database.NewMemoryStore()
+	store.UpsertYouTubeState(&database.YouTubeState{
+		ChannelID:     "UC123",
+		LiveChatID:    sql.NullString{String: "chat-1", Valid: true},
+		NextPageToken: sql.NullString{String: "page-9", Valid: true},
+	})
+
+	var listErr error
+	client := &Client{
+		channelID: "UC123",
+		db:        store,
+		logger:    zap.NewNop(),
+		limiter:   rate.NewLimiter(rate.Inf, 1),
+		state:     StatePolling,
+		listMessages: func(liveChatID, pageToken string) (*youtube.LiveChatMessageListResponse, error) {
+			return &youtube.LiveChatMessageListResponse{NextPageToken: "page-10"}, listErr
+		},
+	}
+
+	// Other API errors keep polling
+	listErr = &googleapi.Error{Code: 500, Errors: []googleapi.ErrorItem{{Reason: "backendError"}}}
+	client.tick()
+	if client.state != StatePolling {
+		t.Fatalf("Expected to keep polling after a backend error, got %s", client.state)
+	}
+
+	listErr = &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "liveChatEnded"}}}
+	client.tick()
+	if client.state != StateEnded || client.backoff != minDiscoveryBackoff || client.nextDiscovery.IsZero() {
+		t.Errorf("Expected ended with a scheduled lookup, got state=%s backoff=%v", client.state, client.backoff)
+	}
+	if state, _ := store.GetYouTubeState("UC123"); state.NextPageToken.Valid {
+		t.Errorf("Expected the page token to be reset, got %+v", state.NextPageToken)
+	}
+
+	// The next broadcast is found once the lookup is due
+	lookups := 0
+	client.findLiveChat = func() error {
+		lookups++
+		return client.saveLiveChat("chat-2")
+	}
+	client.tick()
+	if lookups != 0 {
+		t.Fatal("Lookup must wait for the back-off")
+	}
+	client.nextDiscovery = time.Now().Add(-time.Second)
+	client.tick()
+	if client.state != StatePolling || lookups != 1 {
+		t.Errorf("Expected polling the new broadcast, got state=%s lookups=%d", client.state, lookups)
+	}
+}
+
+func TestSaveLiveChatPageToken(t *testing.T) {
+	store := database.NewMemoryStore()
+	client := &Client{channelID: "UC123", db: store}
+
+	store.UpsertYouTubeState(&database.YouTubeState{
+		ChannelID:     "UC123",
+		LiveChatID:    sql.NullString{String: "chat-1", Valid: true},
+		NextPageToken: sql.NullString{String: "page-3", Valid: true},
+	})
+
+	// Same broadcast rediscovered: resume where we left off
+	if err := client.saveLiveChat("chat-1"); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := store.GetYouTubeState("UC123"); state.NextPageToken.String != "page-3" {
+		t.Errorf("Expected the page token to be kept, got %+v", state.NextPageToken)
+	}
+
+	// New broadcast: start from its first page
+	if err := client.saveLiveChat("chat-2"); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := store.GetYouTubeState("UC123"); state.NextPageToken.Valid || state.LiveChatID.String != "chat-2" {
+		t.Errorf("Expected a fresh page token for the new broadcast, got %+v", state)
+	}
+}
+
+func TestIsChatEnded(t *testing.T) {
+	ended := fmt.Errorf("API call failed: %w", &googleapi.Error{Code: 404, Errors: []googleapi.ErrorItem{{Reason: "liveChatNotFound"}}})
+	if !isChatEnded(ended) {
+		t.Error("Expected wrapped liveChatNotFound to end the chat")
+	}
+	if isChatEnded(errors.New("liveChatEnded")) {
+		t.Error("Plain errors must not end the chat")
+	}
+}
+
+func TestServerPollingInterval(t *testing.T) {
+	client := &Client{pollingInterval: 5 * time.Second, maxPolling: 30 * time.Second}
+
+	tests := []struct {
+		millis int64
+		want   time.Duration
+	}{
+		{0, 5 * time.Second},            // No hint: configured interval
+		{2000, 5 * time.Second},         // Below polling_interval
+		{8500, 8500 * time.Millisecond}, // Server hint
+		{120000, 30 * time.Second},      // Above max_polling_interval
+	}
+	for _, tt := range tests {
+		if got := client.serverInterval(tt.millis); got != tt.want {
+			t.Errorf("serverInterval(%d) = %v, want %v", tt.millis, got, tt.want)
+		}
+	}
+}
+
+func TestMaxPollingIntervalConfig(t *testing.T) {
+	logger := zap.NewNop()
+	tests := []struct {
+		provided, interval, want int
+	}{
+		{0, 5, DefaultMaxPollingInterval},  // Unset
+		{120, 10, 120},                     // Above the old hard cap
+		{10, 10, 10},                       // Fixed interval
+		{5, 10, DefaultMaxPollingInterval}, // Below polling_interval
+	}
+	for _, tt := range tests {
+		if got := maxPollingInterval(tt.provided, tt.interval, logger); got != tt.want {
+			t.Errorf("maxPollingInterval(%d, %d) = %d, want %d", tt.provided, tt.interval, got, tt.want)
+		}
+	}
+}
+
+func TestSupervisorOfflineAt(t *testing.T) {
+	store := database.NewMemoryStore()
+	store.UpsertYouTubeState(&database.YouTubeState{
+		ChannelID:  "UC123",
+		LiveChatID: sql.NullString{String: "chat-1", Valid: true},
+	})
+
+	response := &youtube.LiveChatMessageListResponse{NextPageToken: "page-2", PollingIntervalMillis: 12000}
+	client := &Client{
+		channelID:       "UC123",
+		db:              store,
+		logger:          zap.NewNop(),
+		limiter:         rate.NewLimiter(rate.Inf, 1),
+		pollingInterval: 5 * time.Second,
+		maxPolling:      DefaultMaxPollingInterval * time.Second,
+		state:           StatePolling,
+		listMessages: func(liveChatID, pageToken string) (*youtube.LiveChatMessageListResponse, error) {
+			return response, nil
+		},
+	}
+
+	// The next tick follows the server hint
+	client.tick()
+	if client.state != StatePolling || client.nextPoll != 12*time.Second {
+		t.Fatalf("Expected polling in 12s, got state=%s next=%v", client.state, client.nextPoll)
+	}
+
+	// offlineAt ends the broadcast and falls back to the configured interval
+	response = &youtube.LiveChatMessageListResponse{NextPageToken: "page-3", OfflineAt: "2026-10-16T20:00:00Z", PollingIntervalMillis: 12000}
+	client.tick()
+	if client.state != StateEnded || client.nextDiscovery.IsZero() {
+		t.Errorf("Expected ended with a scheduled lookup, got state=%s", client.state)
+	}
+	if state, _ := store.GetYouTubeState("UC123"); state.NextPageToken.Valid {
+		t.Errorf("Expected the page token to be reset, got %+v", state.NextPageToken)
+	}
+	client.tick()
+	if client.nextPoll != 5*time.Second {
+		t.Errorf("Expected the configured interval once ended, got %v", client.nextPoll)
+	}
+}
+
+func TestSupervisorQuotaBackoff(t *testing.T) {
+	store := database.NewMemoryStore()
+	store.UpsertYouTubeState(&database.YouTubeState{
+		ChannelID:  "UC123",
+		LiveChatID: sql.NullString{String: "chat-1", Valid: true},
+	})
+
+	var listErr error
+	client := &Client{
+		channelID:       "UC123",
+		db:              store,
+		logger:          zap.NewNop(),
+		limiter:         rate.NewLimiter(rate.Inf, 1),
+		pollingInterval: 5 * time.Second,
+		maxPolling:      DefaultMaxPollingInterval * time.Second,
+		state:           StatePolling,
+		listMessages: func(liveChatID, pageToken string) (*youtube.LiveChatMessageListResponse, error) {
+			return &youtube.LiveChatMessageListResponse{}, listErr
+		},
+	}
+
+	listErr = fmt.Errorf("API call failed: %w", &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "quotaExceeded"}}})
+	client.tick()
+	client.tick()
+	if client.state != StatePolling || client.nextPoll != 2*minDiscoveryBackoff {
+		t.Errorf("Expected a growing back-off while polling, got state=%s next=%v", client.state, client.nextPoll)
+	}
+
+	// Quota back: normal interval again
+	listErr = nil
+	client.tick()
+	if client.backoff != 0 || client.nextPoll != 5*time.Second {
+		t.Errorf("Expected the back-off to reset, got backoff=%v next=%v", client.backoff, client.nextPoll)
+	}
+}
+
+func TestSupervisorBootOffline(t *testing.T) {
+	lookups := 0
+	client := &Client{logger: zap.NewNop(), findLiveChat: func() error { lookups++; return nil }}
+
+	client.SetInitialStreamLive(false)
+	client.boot()
+	if client.state != StateEnded || lookups != 0 || !client.nextDiscovery.IsZero() {
+		t.Errorf("Expected to wait for go-live, got state=%s lookups=%d", client.state, lookups)
+	}
+	client.tick()
+	if lookups != 0 {
+		t.Error("No lookup may run before go-live")
+	}
+
+	client.applyStreamStatus(true)
+	if client.state != StatePolling || lookups != 1 {
+		t.Errorf("Expected polling after go-live, got state=%s lookups=%d", client.state, lookups)
+	}
+}
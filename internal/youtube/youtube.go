@@ -3,14 +3,14 @@ package youtube
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
+	"VLX_Robot/internal/commands"
 	"VLX_Robot/internal/config"
 	"VLX_Robot/internal/database"
-	"VLX_Robot/internal/twitch"
+	"VLX_Robot/internal/events"
 	"VLX_Robot/internal/websocket"
 
 	"go.uber.org/zap"
@@ -23,6 +23,8 @@ const (
 	MinPollingInterval     = 5
 	MaxPollingInterval     = 60
 	DefaultPollingInterval = 5
+	// DefaultMaxPollingInterval caps the server polling hint when youtube.max_polling_interval is unset.
+	DefaultMaxPollingInterval = 60
 )
 
 type Client struct {
@@ -30,14 +32,29 @@ type Client struct {
 	channelID       string
 	apiKey          string
 	pollingInterval time.Duration
+	maxPolling      time.Duration // Upper bound of the server polling hint
 	hub             *websocket.Hub
-	db              *database.DB
-	commands        twitch.AudioCommandsMap
+	db              database.Store
+	registry        *commands.Registry // Media commands and cooldowns shared with the Twitch bot
+	roles           *commands.Roles    // Named roles required by commands (nil = built-in roles)
 	logger          *zap.Logger
 	limiter         *rate.Limiter // Rate Limiter
+
+	// Supervisor state, owned by the run goroutine (see supervisor.go)
+	streamStatus  chan bool // Live/offline signals from the Twitch stream lifecycle
+	startOffline  bool      // The Twitch stream was offline at boot: wait for the go-live signal
+	state         string
+	backoff       time.Duration // Delay after the last missed lookup
+	nextDiscovery time.Time     // Next live stream lookup; zero while polling or waiting for a go-live signal
+	nextPoll      time.Duration // Delay before the next tick, from the server's pollingIntervalMillis while polling
+
+	// listMessages overrides the LiveChatMessages.List call (used by tests).
+	listMessages func(liveChatID, pageToken string) (*youtube.LiveChatMessageListResponse, error)
+	// findLiveChat overrides the live chat discovery (used by tests).
+	findLiveChat func() error
 }
 
-func NewClient(cfg config.YouTubeConfig, hub *websocket.Hub, db *database.DB, commands twitch.AudioCommandsMap, logger *zap.Logger) (*Client, error) {
+func NewClient(cfg config.YouTubeConfig, hub *websocket.Hub, db database.Store, registry *commands.Registry, logger *zap.Logger) (*Client, error) {
 	if cfg.APIKey == "" {
 		logger.Info("YouTube module disabled (No API Key provided)")
 		return nil, nil
@@ -55,6 +72,7 @@ func NewClient(cfg config.YouTubeConfig, hub *websocket.Hub, db *database.DB, co
 		)
 		interval = DefaultPollingInterval
 	}
+	maxInterval := maxPollingInterval(cfg.MaxPollingInterval, interval, logger)
 
 	ctx := context.Background()
 	service, err := youtube.NewService(ctx, option.WithAPIKey(cfg.APIKey))
@@ -71,14 +89,32 @@ func NewClient(cfg config.YouTubeConfig, hub *websocket.Hub, db *database.DB, co
 		apiKey:          cfg.APIKey,
 		channelID:       cfg.ChannelID,
 		pollingInterval: time.Duration(interval) * time.Second,
+		maxPolling:      time.Duration(maxInterval) * time.Second,
 		hub:             hub,
 		db:              db,
-		commands:        commands,
+		registry:        registry,
 		logger:          logger,
 		limiter:         limiter,
+		streamStatus:    make(chan bool),
 	}, nil
 }
 
+// maxPollingInterval validates youtube.max_polling_interval against the polling interval (both in seconds).
+func maxPollingInterval(provided, interval int, logger *zap.Logger) int {
+	if provided == 0 {
+		return DefaultMaxPollingInterval
+	}
+	if provided < interval {
+		logger.Warn("Invalid max polling interval, using default",
+			zap.Int("provided", provided),
+			zap.Int("polling_interval", interval),
+			zap.Int("default", DefaultMaxPollingInterval),
+		)
+		return DefaultMaxPollingInterval
+	}
+	return provided
+}
+
 func (c *Client) Start() {
 	if c == nil {
 		return
@@ -86,15 +122,26 @@ func (c *Client) Start() {
 
 	go func() {
 		c.logger.Info("Starting YouTube module initialization...")
+		c.boot()
+		c.run()
+	}()
+}
 
-		if err := c.ensureLiveChatID(); err != nil {
-			c.logger.Error("YouTube Initialization failed. Polling halted.", zap.Error(err))
-			return
-		}
+// SetInitialStreamLive seeds the Twitch stream state before Start. When the stream is offline, no live
+// stream lookup runs until the go-live signal.
+func (c *Client) SetInitialStreamLive(live bool) {
+	if c == nil {
+		return
+	}
+	c.startOffline = !live
+}
 
-		c.logger.Info("YouTube Live Chat ID initialized. Starting Polling Engine.")
-		c.startPolling()
-	}()
+// SetRoles replaces the built-in roles with the configured ones.
+func (c *Client) SetRoles(roles *commands.Roles) {
+	if c == nil {
+		return
+	}
+	c.roles = roles
 }
 
 func (c *Client) ensureLiveChatID() error {
@@ -144,30 +191,27 @@ func (c *Client) ensureLiveChatID() error {
 	liveChatID := details.ActiveLiveChatId
 	c.logger.Info("Found LiveChatID", zap.String("liveChatID", liveChatID))
 
+	return c.saveLiveChat(liveChatID)
+}
+
+// saveLiveChat stores the discovered live chat. The page token is kept when the broadcast is the same
+// (e.g. after a transient error) and cleared for a new one, so each broadcast starts from its first page.
+func (c *Client) saveLiveChat(liveChatID string) error {
 	state := &database.YouTubeState{
 		ChannelID:  c.channelID,
 		LiveChatID: sql.NullString{String: liveChatID, Valid: true},
 		UpdatedAt:  time.Now(),
 	}
+	if prev, err := c.db.GetYouTubeState(c.channelID); err == nil && prev.LiveChatID == state.LiveChatID {
+		state.NextPageToken = prev.NextPageToken
+	}
 
 	if err := c.db.UpsertYouTubeState(state); err != nil {
 		return fmt.Errorf("failed to save state to DB: %w", err)
 	}
-
 	return nil
 }
 
-func (c *Client) startPolling() {
-	ticker := time.NewTicker(c.pollingInterval)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		if err := c.pollChat(); err != nil {
-			c.logger.Error("YouTube polling cycle failed", zap.Error(err))
-		}
-	}
-}
-
 func (c *Client) pollChat() error {
 	// Rate Limit Check
 	if err := c.limiter.Wait(context.Background()); err != nil {
@@ -184,13 +228,12 @@ func (c *Client) pollChat() error {
 	}
 	liveChatID := state.LiveChatID.String
 
-	call := c.service.LiveChatMessages.List(liveChatID, []string{"snippet", "authorDetails"}).MaxResults(200)
-
-	if state.NextPageToken.Valid && state.NextPageToken.String != "" {
-		call.PageToken(state.NextPageToken.String)
+	var pageToken string
+	if state.NextPageToken.Valid {
+		pageToken = state.NextPageToken.String
 	}
 
-	response, err := call.Do()
+	response, err := c.fetchChatMessages(liveChatID, pageToken)
 	if err != nil {
 		return fmt.Errorf("API call failed: %w", err)
 	}
@@ -209,9 +252,26 @@ func (c *Client) pollChat() error {
 		c.processMessages(response.Items)
 	}
 
+	c.nextPoll = c.serverInterval(response.PollingIntervalMillis)
+	if response.OfflineAt != "" {
+		return fmt.Errorf("%w at %s", errChatOffline, response.OfflineAt)
+	}
 	return nil
 }
 
+// fetchChatMessages performs the LiveChatMessages.List call (or the injected lister in tests).
+func (c *Client) fetchChatMessages(liveChatID, pageToken string) (*youtube.LiveChatMessageListResponse, error) {
+	if c.listMessages != nil {
+		return c.listMessages(liveChatID, pageToken)
+	}
+
+	call := c.service.LiveChatMessages.List(liveChatID, []string{"snippet", "authorDetails"}).MaxResults(200)
+	if pageToken != "" {
+		call.PageToken(pageToken)
+	}
+	return call.Do()
+}
+
 func (c *Client) processMessages(items []*youtube.LiveChatMessage) {
 	for _, item := range items {
 		snippet := item.Snippet
@@ -219,14 +279,12 @@ func (c *Client) processMessages(items []*youtube.LiveChatMessage) {
 
 		// Handle Super Chats
 		if snippet.SuperChatDetails != nil {
-			payload := map[string]interface{}{
-				"type":          "youtube_super_chat",
-				"user_name":     author.DisplayName,
-				"amount_string": snippet.SuperChatDetails.AmountDisplayString,
-				"message":       snippet.SuperChatDetails.UserComment,
-				"tier":          snippet.SuperChatDetails.Tier,
-			}
-			c.broadcast(payload)
+			c.broadcast(events.New(events.PlatformYouTube, events.TypeYouTubeSuperChat, item.Id, authorActor(author),
+				events.SuperChatData{
+					AmountString: snippet.SuperChatDetails.AmountDisplayString,
+					Message:      snippet.SuperChatDetails.UserComment,
+					Tier:         snippet.SuperChatDetails.Tier,
+				}))
 			c.logger.Info("Super Chat detected",
 				zap.String("user", author.DisplayName),
 				zap.String("amount", snippet.SuperChatDetails.AmountDisplayString),
@@ -236,62 +294,76 @@ func (c *Client) processMessages(items []*youtube.LiveChatMessage) {
 
 		// Handle Super Stickers
 		if snippet.SuperStickerDetails != nil {
-			payload := map[string]interface{}{
-				"type":          "youtube_super_sticker",
-				"user_name":     author.DisplayName,
-				"amount_string": snippet.SuperStickerDetails.AmountDisplayString,
-				"sticker_alt":   snippet.SuperStickerDetails.SuperStickerMetadata.AltText,
+			var stickerAlt string
+			if snippet.SuperStickerDetails.SuperStickerMetadata != nil {
+				stickerAlt = snippet.SuperStickerDetails.SuperStickerMetadata.AltText
 			}
-			c.broadcast(payload)
+			c.broadcast(events.New(events.PlatformYouTube, events.TypeYouTubeSuperSticker, item.Id, authorActor(author),
+				events.SuperStickerData{
+					AmountString: snippet.SuperStickerDetails.AmountDisplayString,
+					StickerAlt:   stickerAlt,
+				}))
 			c.logger.Info("Super Sticker detected", zap.String("user", author.DisplayName))
 			continue
 		}
 
 		// Handle Text Commands
 		if snippet.DisplayMessage != "" && strings.HasPrefix(snippet.DisplayMessage, "!") {
-			c.handleCommand(snippet.DisplayMessage, author)
+			c.handleCommand(item.Id, snippet.DisplayMessage, author)
 		}
 	}
 }
 
-func (c *Client) handleCommand(message string, author *youtube.LiveChatMessageAuthorDetails) {
+func (c *Client) handleCommand(messageID, message string, author *youtube.LiveChatMessageAuthorDetails) {
 	rawCommand := strings.Fields(message)[0]
 	commandName := strings.ToLower(strings.TrimPrefix(rawCommand, "!"))
 
-	cmdData, exists := c.commands[commandName]
+	cmdData, exists := c.registry.Media(commandName)
 	if !exists {
 		return
 	}
+	commandName = cmdData.Name
 
-	hasPerm := false
-	switch cmdData.Permission {
-	case twitch.PermissionEveryone:
-		hasPerm = true
-	case twitch.PermissionVIP:
-		hasPerm = author.IsChatModerator || author.IsChatOwner
-	case twitch.PermissionSubscriber:
-		hasPerm = author.IsChatSponsor || author.IsChatModerator || author.IsChatOwner
+	if !c.roles.Allows(cmdData.Permission, authorViewer(author)) {
+		return
 	}
 
-	if !hasPerm {
+	// YouTube chat is read-only with an API key, so cooldown notices are only logged
+	verdict := c.registry.Use(commands.Usage{
+		Scope:      "youtube/" + c.channelID,
+		Command:    commandName,
+		User:       author.ChannelId,
+		Privileged: author.IsChatModerator || author.IsChatOwner,
+		Cooldown:   cmdData.Cooldown,
+	})
+	if !verdict.Allowed {
+		c.logger.Info("YouTube command on cooldown", zap.String("command", commandName), zap.String("user", author.DisplayName), zap.Duration("remaining", verdict.Remaining))
 		return
 	}
 
-	c.logger.Info("YouTube Command Triggered", zap.String("command", commandName), zap.String("user", author.DisplayName))
+	clip := c.registry.Pick(cmdData)
+	c.logger.Info("YouTube Command Triggered", zap.String("command", commandName), zap.String("user", author.DisplayName), zap.String("file", clip.Filename))
 
-	payload := twitch.ChatAlertPayload{
-		Type:      "sound_command",
-		Filename:  cmdData.Filename,
-		MediaType: cmdData.MediaType,
-	}
+	c.broadcast(events.New(events.PlatformYouTube, events.TypeSoundCommand, messageID, authorActor(author),
+		events.SoundCommandData{Command: commandName, Filename: clip.Filename, MediaType: clip.MediaType, Volume: cmdData.Volume}))
+}
 
-	data, _ := json.Marshal(payload)
-	c.hub.Broadcast <- data
+func (c *Client) broadcast(evt *events.Event) {
+	c.hub.Publish(evt.FromChannel(c.channelID))
 }
 
-func (c *Client) broadcast(payload map[string]interface{}) {
-	data, err := json.Marshal(payload)
-	if err == nil {
-		c.hub.Broadcast <- data
+// authorViewer maps YouTube author details to the attributes roles are matched against.
+func authorViewer(author *youtube.LiveChatMessageAuthorDetails) commands.Viewer {
+	return commands.Viewer{
+		YouTube:    true,
+		Privileged: author.IsChatModerator || author.IsChatOwner,
+		Sponsor:    author.IsChatSponsor,
+		Moderator:  author.IsChatModerator,
+		Owner:      author.IsChatOwner,
 	}
 }
+
+// authorActor maps YouTube author details to the event Actor.
+func authorActor(author *youtube.LiveChatMessageAuthorDetails) *events.Actor {
+	return &events.Actor{ID: author.ChannelId, DisplayName: author.DisplayName}
+}
@@ -1,14 +1,20 @@
 package youtube
 
 import (
+	"database/sql"
 	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
+	"VLX_Robot/internal/commands"
+	"VLX_Robot/internal/database"
+	"VLX_Robot/internal/events"
 	"VLX_Robot/internal/twitch"
 	"VLX_Robot/internal/websocket"
 
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
 	"google.golang.org/api/youtube/v3"
 )
 
@@ -17,14 +23,14 @@ func TestProcessMessages(t *testing.T) {
 	logger := zap.NewNop()
 	hub := websocket.NewHub(logger)
 	
-	// Create a dummy command map
-	commands := twitch.AudioCommandsMap{
+	// Create a dummy command registry
+	registry := commands.NewRegistry(twitch.AudioCommandsMap{
 		"test": {Filename: "test.mp3", Permission: twitch.PermissionEveryone, MediaType: "audio"},
-	}
+	}, nil)
 
 	client := &Client{
 		hub:      hub,
-		commands: commands,
+		registry: registry,
 		logger:   logger,
 	}
 
@@ -65,22 +71,28 @@ func TestProcessMessages(t *testing.T) {
 	for i := 0; i < 2; i++ {
 		select {
 		case msg := <-hub.Broadcast:
-			var payload map[string]interface{}
-			if err := json.Unmarshal(msg, &payload); err != nil {
+			var evt struct {
+				Platform string                 `json:"platform"`
+				Type     string                 `json:"type"`
+				Data     map[string]interface{} `json:"data"`
+			}
+			if err := json.Unmarshal(mustMarshal(t, msg), &evt); err != nil {
 				t.Fatalf("Failed to unmarshal JSON: %v", err)
 			}
+			if evt.Platform != events.PlatformYouTube {
+				t.Errorf("Expected platform youtube, got %s", evt.Platform)
+			}
 
-			msgType := payload["type"].(string)
-			if msgType == "sound_command" {
-				if payload["filename"] != "test.mp3" {
-					t.Errorf("Expected filename test.mp3, got %v", payload["filename"])
+			if evt.Type == events.TypeSoundCommand {
+				if evt.Data["filename"] != "test.mp3" {
+					t.Errorf("Expected filename test.mp3, got %v", evt.Data["filename"])
 				}
-			} else if msgType == "youtube_super_chat" {
-				if payload["amount_string"] != "$5.00" {
-					t.Errorf("Expected amount $5.00, got %v", payload["amount_string"])
+			} else if evt.Type == events.TypeYouTubeSuperChat {
+				if evt.Data["amount_string"] != "$5.00" {
+					t.Errorf("Expected amount $5.00, got %v", evt.Data["amount_string"])
 				}
 			} else {
-				t.Errorf("Unexpected message type: %s", msgType)
+				t.Errorf("Unexpected message type: %s", evt.Type)
 			}
 			receivedCount++
 		case <-timeout:
@@ -92,3 +104,116 @@ func TestProcessMessages(t *testing.T) {
 		t.Errorf("Expected 2 broadcasts, got %d", receivedCount)
 	}
 }
+
+func TestPollChat(t *testing.T) {
+	logger := zap.NewNop()
+	store := database.NewMemoryStore()
+
+	var requestedTokens []string
+	client := &Client{
+		channelID: "UC123",
+		hub:       websocket.NewHub(logger),
+		db:        store,
+		logger:    logger,
+		limiter:   rate.NewLimiter(rate.Inf, 1),
+		listMessages: func(liveChatID, pageToken string) (*youtube.LiveChatMessageListResponse, error) {
+			if liveChatID != "chat-1" {
+				t.Errorf("Expected liveChatID chat-1, got %s", liveChatID)
+			}
+			requestedTokens = append(requestedTokens, pageToken)
+			return &youtube.LiveChatMessageListResponse{NextPageToken: fmt.Sprintf("page-%d", len(requestedTokens))}, nil
+		},
+	}
+
+	// 1. No state yet: polling must fail without calling the API
+	if err := client.pollChat(); err == nil {
+		t.Fatal("Expected error when no YouTube state exists")
+	}
+
+	// 2. Seed state and poll twice
+	store.UpsertYouTubeState(&database.YouTubeState{
+		ChannelID:  "UC123",
+		LiveChatID: sql.NullString{String: "chat-1", Valid: true},
+	})
+	for i := 0; i < 2; i++ {
+		if err := client.pollChat(); err != nil {
+			t.Fatalf("pollChat failed: %v", err)
+		}
+	}
+
+	// 3. The second call must reuse the token persisted by the first
+	if len(requestedTokens) != 2 || requestedTokens[0] != "" || requestedTokens[1] != "page-1" {
+		t.Errorf("Unexpected page tokens: %v", requestedTokens)
+	}
+
+	state, _ := store.GetYouTubeState("UC123")
+	if state.NextPageToken.String != "page-2" {
+		t.Errorf("Expected stored token page-2, got %s", state.NextPageToken.String)
+	}
+}
+
+func mustMarshal(t *testing.T, evt *events.Event) []byte {
+	t.Helper()
+	data, err := evt.Marshal()
+	if err != nil {
+		t.Fatalf("Failed to marshal event: %v", err)
+	}
+	return data
+}
+
+func TestStreamLifecycle(t *testing.T) {
+	logger := zap.NewNop()
+	db := database.NewMemoryStore()
+	if err := db.UpsertYouTubeState(&database.YouTubeState{
+		ChannelID:     "UC123",
+		LiveChatID:    sql.NullString{String: "chat-1", Valid: true},
+		NextPageToken: sql.NullString{String: "page-7", Valid: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	lookupErr := fmt.Errorf("no active live stream")
+	lookups := 0
+	client := &Client{
+		channelID: "UC123",
+		db:        db,
+		logger:    logger,
+		state:     StatePolling,
+		findLiveChat: func() error {
+			lookups++
+			return lookupErr
+		},
+	}
+
+	// Offline: polling pauses, no lookup is scheduled and the page token is cleared
+	client.applyStreamStatus(false)
+	if client.state != StateEnded || !client.nextDiscovery.IsZero() {
+		t.Errorf("Expected polling to be paused, got state=%s next=%v", client.state, client.nextDiscovery)
+	}
+	state, err := db.GetYouTubeState("UC123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.NextPageToken.Valid || state.LiveChatID.String != "chat-1" {
+		t.Errorf("Expected only NextPageToken to be reset, got %+v", state)
+	}
+
+	// Online: discovery runs immediately and is retried while the YouTube stream is not up yet
+	client.applyStreamStatus(true)
+	if client.state != StateSearching || lookups != 1 || client.backoff != minDiscoveryBackoff {
+		t.Errorf("Unexpected state after failed lookup: state=%s lookups=%d backoff=%v", client.state, lookups, client.backoff)
+	}
+
+	// Retry is not due yet
+	client.tick()
+	if lookups != 1 {
+		t.Errorf("Expected retry to wait, got %d lookups", lookups)
+	}
+
+	lookupErr = nil
+	client.nextDiscovery = time.Now().Add(-time.Second)
+	client.tick()
+	if client.state != StatePolling || lookups != 2 || client.backoff != 0 {
+		t.Errorf("Expected polling after successful lookup: state=%s lookups=%d backoff=%v", client.state, lookups, client.backoff)
+	}
+}
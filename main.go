@@ -1,8 +1,14 @@
 package main
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
+	"VLX_Robot/internal/commands"
 	"VLX_Robot/internal/config"
 	"VLX_Robot/internal/database"
 	"VLX_Robot/internal/server"
@@ -24,8 +30,25 @@ func main() {
 		logger.Fatal("Config load error", zap.Error(err))
 	}
 
-	// 3. Initialize Database connection
-	db, err := database.NewConnection(cfg.Database, logger)
+	// Dedicated migration command: ./VLX_Robot migrate [up|down [steps]]
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		pg, err := database.NewConnection(cfg.Database, logger)
+		if err != nil {
+			logger.Fatal("DB connection error", zap.Error(err))
+		}
+		err = runMigrations(pg, os.Args[2:], logger)
+		pg.Close()
+		if err != nil {
+			// Exit only after the connection is closed and the log is flushed
+			logger.Error("Migration failed", zap.Error(err))
+			logger.Sync()
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 3. Initialize Storage backend (PostgreSQL or in-memory)
+	db, err := database.Open(cfg.Database, logger)
 	if err != nil {
 		logger.Fatal("DB connection error", zap.Error(err))
 	}
@@ -33,33 +56,87 @@ func main() {
 
 	// 4. Start WebSocket Hub
 	hub := websocket.NewHub(logger)
+	if cfg.Server.HistoryRetention > 0 {
+		retention := time.Duration(cfg.Server.HistoryRetention) * time.Minute
+		if err := hub.EnableHistory(db, retention); err != nil {
+			logger.Error("Event history disabled", zap.Error(err))
+		}
+	}
 	go hub.Run()
 
 	// 5. Initialize Twitch API Client (EventSub)
-	monitorChannels := []string{cfg.Twitch.ChannelName}
+	var monitorChannels []string
+	for _, ch := range cfg.Twitch.MonitoredChannels() {
+		monitorChannels = append(monitorChannels, ch.Name)
+	}
 	twitchClient, err := twitch.NewClient(cfg.Twitch, monitorChannels, cfg.Server.BaseURL, hub, db, logger)
 	if err != nil {
 		logger.Error("Twitch Client init failed", zap.Error(err))
 	} else {
+		twitchClient.StartTokenManager()
 		if err := twitchClient.StartMonitoring(monitorChannels); err != nil {
 			logger.Error("Twitch monitoring failed", zap.Error(err))
 		}
 	}
 
-	// 6. Initialize Twitch Chat Bot
-	cmdMap, err := twitch.ScanAudioCommands(filepath.Join("static", "chat"), logger)
+	// 6. Initialize Twitch Chat Bot (cooldowns are shared with YouTube commands)
+	cooldowns := commands.NewCooldowns(commands.PolicyFromConfig(cfg.Commands, cfg.Twitch.Chat.CommandCooldown))
+	if cfg.Commands.CooldownNotice == commands.NoticeWhisper && twitchClient != nil {
+		twitchClient.EnableWhispers()
+	}
+	roles, err := commands.RolesFromConfig(cfg.Commands.Roles)
+	if err != nil {
+		logger.Fatal("Invalid command roles", zap.Error(err))
+	}
+	mediaDir := filepath.Join("static", "chat")
+	cmdMap, err := twitch.ScanAudioCommands(mediaDir, roles, logger)
+	registry := commands.NewRegistry(cmdMap, cooldowns)
+	var chatClient *twitch.ChatClient
 	if err != nil {
 		logger.Warn("Audio commands scan failed", zap.Error(err))
 	} else {
-		chatClient := twitch.NewChatClient(cfg.Twitch.Chat, hub, cmdMap, logger)
+		chatClient = twitch.NewChatClient(cfg.Twitch.Chat, cfg.Twitch.ChatChannels(), hub, registry, db, logger)
+		chatClient.SetRoles(roles)
+		var streamInfo commands.StreamInfo
+		if twitchClient != nil {
+			streamInfo = twitchClient
+		}
+		chatClient.SetTemplates(commands.NewEngine(db, streamInfo, logger))
+		if twitchClient != nil {
+			chatClient.SetWhisperer(twitchClient)
+			chatClient.SetFollowChecker(twitchClient)
+		}
 		chatClient.Start()
+		if twitchClient != nil {
+			twitchClient.SetChatSender(chatClient)
+		}
+	}
+
+	// Hot reload of the media command library: new sounds work without restarting the bot
+	if cfg.Commands.ReloadInterval >= 0 {
+		watcher := twitch.NewLibraryWatcher(mediaDir, roles, time.Duration(cfg.Commands.ReloadInterval)*time.Second, registry, logger)
+		if cfg.Commands.AnnounceChanges && chatClient != nil {
+			watcher.SetAnnouncer(chatClient.Announce)
+		}
+		watcher.Start()
 	}
 
 	// 7. Initialize YouTube Client (Polling) with Rate Limiting
-	youtubeClient, err := youtube.NewClient(cfg.YouTube, hub, db, cmdMap, logger)
+	youtubeClient, err := youtube.NewClient(cfg.YouTube, hub, db, registry, logger)
 	if err != nil {
 		logger.Error("YouTube Client init failed", zap.Error(err))
 	} else if youtubeClient != nil {
+		youtubeClient.SetRoles(roles)
+
+		// Follow the Twitch stream lifecycle: poll YouTube only while the primary channel is live
+		if twitchClient != nil {
+			twitchClient.OnLiveChange(func(channel string, live bool) {
+				if strings.EqualFold(channel, cfg.Twitch.PrimaryChannel()) {
+					youtubeClient.SetStreamLive(live)
+				}
+			})
+			youtubeClient.SetInitialStreamLive(twitchClient.IsLive(cfg.Twitch.PrimaryChannel()))
+		}
 		youtubeClient.Start()
 	}
 
@@ -81,3 +158,34 @@ func main() {
 		logger.Fatal("Main HTTP Server error", zap.Error(err))
 	}
 }
+
+// runMigrations applies or rolls back schema migrations.
+func runMigrations(db *database.DB, args []string, logger *zap.Logger) error {
+	direction := "up"
+	if len(args) > 0 {
+		direction = args[0]
+	}
+
+	switch direction {
+	case "up":
+		if err := db.Migrate(); err != nil {
+			return err
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid rollback steps %q", args[1])
+			}
+			steps = n
+		}
+		if err := db.Rollback(steps); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown migrate direction %q (use 'up' or 'down')", direction)
+	}
+	logger.Info("Migrations completed", zap.String("direction", direction))
+	return nil
+}
@@ -3,6 +3,13 @@ const alertQueue = [];
 let isAlertShowing = false;
 let basePath = '';
 
+// Last event sequence seen, kept across browser-source reloads so missed events are replayed
+const seqKey = 'vlx_last_seq_alerts';
+let lastSeq = parseInt(localStorage.getItem(seqKey) || '0', 10);
+
+// Optional ?channel=<login> filter: events from other channels are skipped
+const channelFilter = (new URLSearchParams(window.location.search).get('channel') || '').toLowerCase();
+
 // Calculate master volume (normalized 0.0 - 1.0), defaulting to 1.0 if undefined
 const masterVolume = (window.VLX_CONFIG && typeof window.VLX_CONFIG.VOLUME === 'number') 
     ? (window.VLX_CONFIG.VOLUME / 100) 
@@ -25,7 +32,7 @@ function connect() {
     // Derive base path from WebSocket path (e.g., /vlxrobot/ws -> /vlxrobot)
     basePath = wsPath.substring(0, wsPath.lastIndexOf('/'));
 
-    const socket = new WebSocket(`${protocol}//${host}${wsPath}`);
+    const socket = new WebSocket(`${protocol}//${host}${wsPath}?topics=alerts&last_seq=${lastSeq}`);
 
     socket.onopen = () => console.log("[System] Alert Overlay Connected");
 
@@ -42,6 +49,13 @@ function connect() {
     socket.onmessage = (event) => {
         try {
             const data = JSON.parse(event.data);
+            if (data.seq) {
+                lastSeq = data.seq;
+                localStorage.setItem(seqKey, lastSeq);
+            }
+            if (channelFilter && data.channel && data.channel.toLowerCase() !== channelFilter) {
+                return;
+            }
             alertQueue.push(data);
             processQueue();
         } catch (err) {
@@ -55,7 +69,11 @@ function processQueue() {
     if (isAlertShowing || alertQueue.length === 0) return;
 
     isAlertShowing = true;
-    const data = alertQueue.shift();
+    const evt = alertQueue.shift();
+
+    // Event envelope: { version, platform, type, id, timestamp, actor, data }
+    const actor = (evt.actor && evt.actor.display_name) || '';
+    const data = evt.data || {};
 
     // Default configuration
     let config = {
@@ -65,77 +83,84 @@ function processQueue() {
     };
 
     // Map Event Types to Visual Assets
-    switch (data.type) {
+    switch (evt.type) {
         case 'twitch_follow':
             config.title = "New Follower";
-            config.detail = data.user_name;
+            config.detail = actor;
             config.image = `${basePath}/static/alerts/follow.mp4`;
             break;
 
         case 'twitch_subscribe':
             config.title = `New Tier ${data.tier.charAt(0)} Sub!`;
-            config.detail = data.user_name;
+            config.detail = actor;
             config.image = `${basePath}/static/alerts/sub.mp4`;
             break;
 
         case 'twitch_resubscribe':
-            config.title = `${data.cumulative_months || data.months} Month Resub!`;
-            config.detail = data.user_name;
+            config.title = `${data.cumulative_months} Month Resub!`;
+            config.detail = actor;
             config.message = data.message;
             config.image = `${basePath}/static/alerts/sub.mp4`;
             config.duration = data.message ? 8000 : 6000;
             break;
 
         case 'twitch_gift_sub':
-            config.detail = data.is_anonymous ? "An Anonymous Gifter" : data.gifter_name;
-            config.title = `Gifted ${data.total_gifts} Tier ${data.tier.charAt(0)} Sub(s)!`;
+            config.detail = data.is_anonymous ? "An Anonymous Gifter" : actor;
+            config.title = `Gifted ${data.total} Tier ${data.tier.charAt(0)} Sub(s)!`;
             config.image = `${basePath}/static/alerts/sub.mp4`;
             config.duration = 8000;
             break;
 
         case 'twitch_cheer':
             config.title = `${data.bits} Bit Cheer!`;
-            config.detail = data.is_anonymous ? "Anonymous" : data.user_name;
+            config.detail = data.is_anonymous ? "Anonymous" : actor;
             config.message = data.message;
             config.image = `${basePath}/static/alerts/cheer.mp4`;
             break;
 
         case 'twitch_raid':
             config.title = "Incoming Raid!";
-            config.detail = `${data.raider_name} raiding with ${data.viewers} viewers!`;
+            config.detail = `${actor} raiding with ${data.viewers} viewers!`;
             config.image = `${basePath}/static/alerts/raid.mp4`;
             config.duration = 10000;
             break;
 
+        case 'twitch_redemption':
+            config.title = data.reward_title;
+            config.detail = `${actor} (${data.cost} points)`;
+            config.message = data.message || data.user_input || "";
+            config.image = `${basePath}/static/alerts/follow.mp4`;
+            break;
+
         case 'youtube_member':
             config.title = "New Member";
-            config.detail = data.user_name;
+            config.detail = actor;
             config.image = `${basePath}/static/alerts/follow.mp4`;
             break;
 
         case 'youtube_super_chat':
             config.title = `Super Chat: ${data.amount_string}`;
-            config.detail = data.user_name;
+            config.detail = actor;
             config.message = data.message || "";
             config.image = `${basePath}/static/alerts/cheer.mp4`;
             break;
 
         case 'youtube_super_sticker':
             config.title = `Super Sticker: ${data.amount_string}`;
-            config.detail = data.user_name;
+            config.detail = actor;
             config.message = data.sticker_alt || "";
             config.image = `${basePath}/static/alerts/cheer.mp4`;
             break;
 
         case 'stream_tip':
             config.title = "New Donation!";
-            config.detail = `${data.user_name} (${data.amount_string})`;
+            config.detail = `${actor} (${data.amount_string})`;
             config.message = data.message || "";
             config.image = `${basePath}/static/alerts/cheer.mp4`;
             break;
 
         default:
-            console.warn("[Warn] Unhandled event type:", data.type);
+            console.warn("[Warn] Unhandled event type:", evt.type);
             isAlertShowing = false;
             processQueue();
             return;
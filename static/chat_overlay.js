@@ -3,6 +3,13 @@ const mediaQueue = [];
 let isPlaying = false;
 let basePath = '';
 
+// Last event sequence seen, kept across browser-source reloads so missed events are replayed
+const seqKey = 'vlx_last_seq_media';
+let lastSeq = parseInt(localStorage.getItem(seqKey) || '0', 10);
+
+// Optional ?channel=<login> filter: events from other channels are skipped
+const channelFilter = (new URLSearchParams(window.location.search).get('channel') || '').toLowerCase();
+
 // Calculate master volume (0.0 to 1.0)
 const masterVolume = (window.VLX_CONFIG && typeof window.VLX_CONFIG.VOLUME === 'number') 
     ? (window.VLX_CONFIG.VOLUME / 100) 
@@ -16,7 +23,7 @@ function connect() {
     const wsPath = (window.VLX_CONFIG && window.VLX_CONFIG.WEBSOCKET_PATH) || '/vlxrobot/ws';
     basePath = wsPath.substring(0, wsPath.lastIndexOf('/'));
 
-    const socket = new WebSocket(`${protocol}//${host}${wsPath}`);
+    const socket = new WebSocket(`${protocol}//${host}${wsPath}?topics=media&last_seq=${lastSeq}`);
 
     socket.onopen = () => console.log("[System] FX Overlay Connected.");
     socket.onclose = (event) => {
@@ -31,8 +38,15 @@ function connect() {
     socket.onmessage = (event) => {
         try {
             const data = JSON.parse(event.data);
-            if (data.type === 'sound_command') {
-                mediaQueue.push(data);
+            if (data.seq) {
+                lastSeq = data.seq;
+                localStorage.setItem(seqKey, lastSeq);
+            }
+            if (channelFilter && data.channel && data.channel.toLowerCase() !== channelFilter) {
+                return;
+            }
+            if (data.type === 'sound_command' && data.data) {
+                mediaQueue.push(data.data);
                 processQueue();
             }
         } catch (err) {
@@ -48,17 +62,22 @@ function processQueue() {
     const item = mediaQueue.shift();
     const src = `${basePath}/static/chat/${item.filename}`;
 
+    // Per-command volume (1-100) scales the master volume
+    const volume = (typeof item.volume === 'number' && item.volume > 0)
+        ? masterVolume * (item.volume / 100)
+        : masterVolume;
+
     if (item.media_type === 'video') {
-        playVideo(src);
+        playVideo(src, volume);
     } else {
-        playAudio(src);
+        playAudio(src, volume);
     }
 }
 
-function playAudio(src) {
+function playAudio(src, volume) {
     console.log("[Playback] Starting AUDIO:", src);
     const audio = new Audio(src);
-    audio.volume = masterVolume; // Apply Volume
+    audio.volume = volume; // Apply Volume
 
     audio.play().catch(e => {
         console.warn("[Warning] Audio playback failed:", e);
@@ -78,11 +97,11 @@ function playAudio(src) {
     };
 }
 
-function playVideo(src) {
+function playVideo(src, volume) {
     console.log("[Playback] Starting VIDEO:", src);
     videoElement.src = src;
     videoElement.style.display = 'block';
-    videoElement.volume = masterVolume; // Apply Volume
+    videoElement.volume = volume; // Apply Volume
 
     videoElement.play().catch(e => {
         console.warn("[Warning] Video playback failed:", e);
@@ -2,8 +2,15 @@ const wsPath = (window.VLX_CONFIG && window.VLX_CONFIG.WEBSOCKET_PATH) || '/vlxr
 const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
 const host = window.location.host;
 
+// Last event sequence seen, kept across browser-source reloads so missed events are replayed
+const seqKey = 'vlx_last_seq_emotes';
+let lastSeq = parseInt(localStorage.getItem(seqKey) || '0', 10);
+
+// Optional ?channel=<login> filter: events from other channels are skipped
+const channelFilter = (new URLSearchParams(window.location.search).get('channel') || '').toLowerCase();
+
 function connect() {
-    const socket = new WebSocket(`${protocol}//${host}${wsPath}`);
+    const socket = new WebSocket(`${protocol}//${host}${wsPath}?topics=emotes&last_seq=${lastSeq}`);
 
     socket.onopen = () => console.log("Emote Wall Connected.");
 
@@ -15,8 +22,15 @@ function connect() {
     socket.onmessage = (event) => {
         try {
             const data = JSON.parse(event.data);
-            if (data.type === 'emote_wall' && data.emotes) {
-                spawnEmotes(data.emotes);
+            if (data.seq) {
+                lastSeq = data.seq;
+                localStorage.setItem(seqKey, lastSeq);
+            }
+            if (channelFilter && data.channel && data.channel.toLowerCase() !== channelFilter) {
+                return;
+            }
+            if (data.type === 'emote_wall' && data.data && data.data.emotes) {
+                spawnEmotes(data.data.emotes);
             }
         } catch (e) {
             console.error(e);
@@ -0,0 +1,143 @@
+const wsPath = (window.VLX_CONFIG && window.VLX_CONFIG.WEBSOCKET_PATH) || '/vlxrobot/ws';
+const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+const host = window.location.host;
+
+// Last event sequence seen, kept across browser-source reloads so missed events are replayed
+const seqKey = 'vlx_last_seq_progress';
+let lastSeq = parseInt(localStorage.getItem(seqKey) || '0', 10);
+
+// Optional ?channel=<login> filter: events from other channels are skipped
+const channelFilter = (new URLSearchParams(window.location.search).get('channel') || '').toLowerCase();
+
+// How long the final result stays on screen after an "end" event
+const resultDuration = 15000;
+const hideTimers = {};
+
+function connect() {
+    const socket = new WebSocket(`${protocol}//${host}${wsPath}?topics=progress&last_seq=${lastSeq}`);
+
+    socket.onopen = () => console.log("[System] Progress Overlay Connected");
+
+    socket.onclose = () => {
+        console.warn("[System] Disconnected. Reconnecting in 3s...");
+        setTimeout(connect, 3000);
+    };
+
+    socket.onmessage = (event) => {
+        try {
+            const evt = JSON.parse(event.data);
+            if (evt.seq) {
+                lastSeq = evt.seq;
+                localStorage.setItem(seqKey, lastSeq);
+            }
+            if (channelFilter && evt.channel && evt.channel.toLowerCase() !== channelFilter) {
+                return;
+            }
+            const data = evt.data || {};
+            switch (evt.type) {
+                case 'twitch_hype_train':
+                    renderHypeTrain(data);
+                    break;
+                case 'twitch_poll':
+                    renderPoll(data);
+                    break;
+                case 'twitch_prediction':
+                    renderPrediction(data);
+                    break;
+            }
+        } catch (err) {
+            console.error("[Error] Failed to parse payload:", err);
+        }
+    };
+}
+
+// --- Panels ---
+function showPanel(kind, phase) {
+    const panel = document.getElementById(`panel-${kind}`);
+    clearTimeout(hideTimers[kind]);
+    panel.classList.remove('hidden');
+    if (phase === 'end') {
+        hideTimers[kind] = setTimeout(() => panel.classList.add('hidden'), resultDuration);
+    }
+    return panel;
+}
+
+function renderHypeTrain(data) {
+    const panel = showPanel('hype_train', data.phase);
+    const percent = data.goal > 0 ? Math.min(100, (data.progress / data.goal) * 100) : 100;
+
+    panel.querySelector('.progress-title').textContent = data.phase === 'end'
+        ? `Hype Train ended at Level ${data.level}!`
+        : `Hype Train Level ${data.level}`;
+    panel.querySelector('.progress-detail').textContent = data.phase === 'end'
+        ? `${data.total} total`
+        : `${data.progress} / ${data.goal}`;
+    panel.querySelector('.progress-fill').style.width = `${percent}%`;
+}
+
+function renderPoll(data) {
+    const panel = showPanel('poll', data.phase);
+    const choices = data.choices || [];
+    const total = choices.reduce((sum, c) => sum + c.votes, 0);
+    const top = Math.max(0, ...choices.map(c => c.votes));
+
+    panel.querySelector('.progress-title').textContent = data.title;
+    panel.querySelector('.progress-detail').textContent = data.phase === 'end' ? 'Poll closed' : `${total} votes`;
+    renderOptions(panel, choices.map(c => ({
+        title: c.title,
+        value: c.votes,
+        label: `${c.votes}`,
+        total: total,
+        winner: data.phase === 'end' && c.votes === top && top > 0
+    })));
+}
+
+function renderPrediction(data) {
+    const panel = showPanel('prediction', data.phase);
+    const outcomes = data.outcomes || [];
+    const total = outcomes.reduce((sum, o) => sum + o.channel_points, 0);
+
+    let detail = `${total} points`;
+    if (data.phase === 'lock') detail = 'Predictions locked';
+    if (data.phase === 'end') detail = data.status === 'canceled' ? 'Prediction canceled, points refunded' : 'Prediction resolved';
+
+    panel.querySelector('.progress-title').textContent = data.title;
+    panel.querySelector('.progress-detail').textContent = detail;
+    renderOptions(panel, outcomes.map(o => ({
+        title: o.title,
+        value: o.channel_points,
+        label: `${o.channel_points} (${o.users})`,
+        total: total,
+        color: o.color,
+        winner: data.winning_outcome_id === o.id
+    })));
+}
+
+// renderOptions draws one tally row per option with a proportional bar.
+function renderOptions(panel, options) {
+    const list = panel.querySelector('.progress-options');
+    list.innerHTML = '';
+    options.forEach(opt => {
+        const row = document.createElement('div');
+        row.className = 'progress-option' + (opt.winner ? ' winner' : '');
+
+        const label = document.createElement('div');
+        label.className = 'progress-option-label';
+        label.textContent = `${opt.title} — ${opt.label}`;
+
+        const bar = document.createElement('div');
+        bar.className = 'progress-bar';
+        const fill = document.createElement('div');
+        fill.className = 'progress-fill';
+        fill.style.width = opt.total > 0 ? `${(opt.value / opt.total) * 100}%` : '0%';
+        if (opt.color === 'pink') fill.style.backgroundColor = '#f5009b';
+        if (opt.color === 'blue') fill.style.backgroundColor = '#387aff';
+        bar.appendChild(fill);
+
+        row.appendChild(label);
+        row.appendChild(bar);
+        list.appendChild(row);
+    });
+}
+
+connect();